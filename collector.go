@@ -0,0 +1,56 @@
+package bear
+
+import (
+	"expvar"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+// ErrorCountMetric is the name of the counter used by CollectErrors
+const ErrorCountMetric = "bear_errors_total"
+
+// collector holds the registry used to count errors, it's wrapped so it can be stored in an atomic.Value
+type collector struct {
+	registry *metrics.Registry
+}
+
+// errorCollector is the current collector, a nil registry means errors are not being counted
+var errorCollector atomic.Value
+
+// CollectErrors counts every error created with New, Wrap, Template.New or Template.Wrap in the registry.
+// The counters are named ErrorCountMetric and are labeled with the errType, code and labels of the error,
+// multiple labels are sorted and joined with commas. Calling CollectErrors with nil stops collecting errors
+func CollectErrors(r *metrics.Registry) {
+	errorCollector.Store(collector{registry: r})
+}
+
+// collect increments the error counter for the error if errors are being collected
+func collect(e *Error) {
+	c, _ := errorCollector.Load().(collector)
+	if c.registry == nil {
+		return
+	}
+
+	labels := metrics.Labels{
+		"errType": "",
+		"code":    "",
+		"labels":  strings.Join(mapToArray(e.labels), ","),
+	}
+	if e.errType != nil {
+		labels["errType"] = string(*e.errType)
+	}
+	if e.code != nil {
+		labels["code"] = strconv.Itoa(*e.code)
+	}
+
+	c.registry.Counter(ErrorCountMetric, labels).Incr()
+}
+
+// PublishErrorCounts publishes the number of errors counted in the registry grouped by errType as an expvar with the name.
+// Errors without an errType are grouped under the empty string. Like expvar.Publish it panics if the name is already in use
+func PublishErrorCounts(r *metrics.Registry, name string) {
+	expvar.Publish(name, r.GroupVar(ErrorCountMetric, "errType"))
+}
@@ -0,0 +1,89 @@
+package bear
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+func TestCollectErrors(t *testing.T) {
+	r := metrics.NewRegistry()
+	CollectErrors(r)
+	defer CollectErrors(nil)
+
+	dbErr := NewType("DB Error")
+	tmpl := NewTemplate(WithErrType(dbErr), WithLabels("users"))
+
+	New(WithErrType(dbErr), WithCode(500))
+	New(WithErrType(dbErr), WithCode(500))
+	Wrap(errors.New("std error"), WithLabels("retry", "db"))
+	tmpl.New()
+	tmpl.Wrap(errors.New("std error"))
+	func() {
+		defer New().WrapPanic()
+		panic("panicking")
+	}()
+
+	// converting and printing errors should not be counted
+	_, _ = AsBerr(errors.New("std error"))
+	_ = New(WithParent(errors.New("std error"))).Error()
+
+	want := []metrics.Sample{
+		{Name: ErrorCountMetric, Labels: metrics.Labels{"errType": "", "code": "", "labels": ""}, Value: 2},
+		{Name: ErrorCountMetric, Labels: metrics.Labels{"errType": "", "code": "", "labels": "db,retry"}, Value: 1},
+		{Name: ErrorCountMetric, Labels: metrics.Labels{"errType": "DB Error", "code": "", "labels": "users"}, Value: 2},
+		{Name: ErrorCountMetric, Labels: metrics.Labels{"errType": "Panic Error", "code": "", "labels": ""}, Value: 1},
+		{Name: ErrorCountMetric, Labels: metrics.Labels{"errType": "DB Error", "code": "500", "labels": ""}, Value: 2},
+	}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectErrors() snapshot = \n%v, want \n%v", got, want)
+	}
+
+	// errors should not be counted once collection has stopped
+	CollectErrors(nil)
+	New(WithErrType(dbErr), WithCode(500))
+	if got := r.Counter(ErrorCountMetric, metrics.Labels{"errType": "DB Error", "code": "500", "labels": ""}).GetValue(); got != 2 {
+		t.Errorf("CollectErrors(nil) error was still counted, got %d", got)
+	}
+}
+
+// publishedCounts counts the published test vars
+var publishedCounts int64
+
+func TestPublishErrorCounts(t *testing.T) {
+	// expvars can only be published once so the name must be unique even when the test is run more than once
+	name := fmt.Sprintf("test_error_counts_%d", atomic.AddInt64(&publishedCounts, 1))
+	r := metrics.NewRegistry()
+	PublishErrorCounts(r, name)
+	CollectErrors(r)
+	defer CollectErrors(nil)
+
+	dbErr := NewType("DB Error")
+	New(WithErrType(dbErr), WithCode(500))
+	New(WithErrType(dbErr), WithLabels("users"))
+	New()
+
+	rec := httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("expvar.Handler() returned invalid json, %v", err)
+	}
+	var counts map[string]int
+	if err := json.Unmarshal(raw[name], &counts); err != nil {
+		t.Fatalf("expvar.Handler() returned invalid counts, %v", err)
+	}
+
+	want := map[string]int{"DB Error": 2, "": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("PublishErrorCounts() = %v, want %v", counts, want)
+	}
+}
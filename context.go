@@ -0,0 +1,80 @@
+package bear
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	CanceledErr         = NewType("Context Canceled")
+	DeadlineExceededErr = NewType("Context Deadline Exceeded")
+)
+
+// contextKey is the key for the error options stored in a context
+type contextKey struct{}
+
+// withContextOpts returns a copy of the context with the options added to any options already in the context
+func withContextOpts(ctx context.Context, opts ...ErrOption) context.Context {
+	return context.WithValue(ctx, contextKey{}, joinOpts(contextOpts(ctx), opts))
+}
+
+// contextOpts returns the error options stored in the context
+func contextOpts(ctx context.Context) []ErrOption {
+	if ctx == nil {
+		return nil
+	}
+
+	opts, _ := ctx.Value(contextKey{}).([]ErrOption)
+	return opts
+}
+
+// WithContextTags returns a copy of the context with the tag, the tag is added to every error created with NewCtx or WrapCtx
+func WithContextTags(ctx context.Context, name string, value interface{}) context.Context {
+	return withContextOpts(ctx, WithTag(name, value))
+}
+
+// WithContextLabels returns a copy of the context with the labels, the labels are added to every error created with NewCtx or WrapCtx
+func WithContextLabels(ctx context.Context, labels ...string) context.Context {
+	return withContextOpts(ctx, WithLabels(labels...))
+}
+
+// ContextTemplate returns a copy of the context with the template, the template's options are added to every error
+// created with NewCtx or WrapCtx. Templates can be layered, later templates override the options of earlier ones
+func ContextTemplate(ctx context.Context, tmpl Template) context.Context {
+	return withContextOpts(ctx, tmpl.opts...)
+}
+
+// NewCtx creates a new bear.Error with the trace, tags, labels and templates stored in the context.
+// The options are applied after the context's options so they take precedence.
+// If the context is done the context's error is added as a parent, see contextParent
+func NewCtx(ctx context.Context, opts ...ErrOption) *Error {
+	return newError(2, joinOpts(contextTrace(ctx), contextOpts(ctx), opts, contextParent(ctx, nil)))
+}
+
+// WrapCtx creates a new bear.Error with parent err and the trace, tags, labels and templates stored in the context.
+// The options are applied after the context's options so they take precedence.
+// If the context is done the context's error is added as a parent, see contextParent
+func WrapCtx(ctx context.Context, err error, opts ...ErrOption) *Error {
+	return newError(2, joinOpts(contextTrace(ctx), contextOpts(ctx), opts, []ErrOption{WithParent(err)}, contextParent(ctx, err)))
+}
+
+// contextParent returns an option that adds the context's error as a typed parent if the context is done,
+// the parent has the CanceledErr or DeadlineExceededErr type. Nothing is added if err already wraps the context's error
+func contextParent(ctx context.Context, err error) []ErrOption {
+	// IsAny is used since errors.Is does not walk errors with multiple parents before go 1.20
+	if ctx == nil || ctx.Err() == nil || IsAny(err, ctx.Err()) {
+		return nil
+	}
+
+	errType := CanceledErr
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		errType = DeadlineExceededErr
+	}
+
+	parent := buildError(1, []ErrOption{
+		WithErrType(errType),
+		WithParent(ctx.Err()),
+		WithStackDepth(StackNone),
+	})
+	return []ErrOption{WithParent(parent)}
+}
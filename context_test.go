@@ -0,0 +1,138 @@
+package bear
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCtx(t *testing.T) {
+	dbErr := NewType("DB Error")
+	base := context.Background()
+	ctx := WithContextTags(base, "requestID", "abc")
+	ctx = WithContextLabels(ctx, "api")
+	ctx = ContextTemplate(ctx, NewTemplate(WithErrType(dbErr), WithCode(500)))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	expired, cancelExpired := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name       string
+		err        *Error
+		wantTags   map[string]interface{}
+		wantLabels []string
+		wantCode   int
+		wantType   ErrType
+		wantCtxErr ErrType
+	}{
+		{
+			"empty context",
+			NewCtx(base, WithCode(400)),
+			map[string]interface{}{},
+			nil,
+			400,
+			"",
+			"",
+		},
+		{
+			"context data",
+			NewCtx(ctx, WithLabels("users")),
+			map[string]interface{}{"requestID": "abc"},
+			[]string{"api", "users"},
+			500,
+			dbErr,
+			"",
+		},
+		{
+			"options override the context",
+			NewCtx(ctx, WithCode(404), WithTag("requestID", "def")),
+			map[string]interface{}{"requestID": "def"},
+			[]string{"api"},
+			404,
+			dbErr,
+			"",
+		},
+		{
+			"wrapped error",
+			WrapCtx(ctx, io.EOF),
+			map[string]interface{}{"requestID": "abc"},
+			[]string{"api"},
+			500,
+			dbErr,
+			"",
+		},
+		{
+			"canceled context",
+			NewCtx(canceled),
+			map[string]interface{}{"requestID": "abc"},
+			[]string{"api"},
+			500,
+			dbErr,
+			CanceledErr,
+		},
+		{
+			"expired context",
+			WrapCtx(expired, io.EOF),
+			map[string]interface{}{"requestID": "abc"},
+			[]string{"api"},
+			500,
+			dbErr,
+			DeadlineExceededErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tags := tt.err.GetTags(); !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("GetTags() = %v, want %v", tags, tt.wantTags)
+			}
+			if labels := tt.err.GetLabels(); !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", labels, tt.wantLabels)
+			}
+			if code, _ := tt.err.GetCode(); code != tt.wantCode {
+				t.Errorf("GetCode() = %d, want %d", code, tt.wantCode)
+			}
+			if errType, _ := tt.err.GetErrType(); errType != tt.wantType {
+				t.Errorf("GetErrType() = %s, want %s", errType, tt.wantType)
+			}
+
+			ctxErr := FindType(tt.err, CanceledErr)
+			if ctxErr == nil {
+				ctxErr = FindType(tt.err, DeadlineExceededErr)
+			}
+			switch {
+			case tt.wantCtxErr == "" && ctxErr != nil:
+				t.Errorf("context error parent = %v, want none", ctxErr)
+			case tt.wantCtxErr != "" && (ctxErr == nil || *ctxErr.errType != tt.wantCtxErr):
+				t.Errorf("context error parent = %v, want %s", ctxErr, tt.wantCtxErr)
+			}
+		})
+	}
+}
+
+func TestWrapCtx_ContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	e := WrapCtx(ctx, ctx.Err())
+	if !errors.Is(e, context.Canceled) {
+		t.Errorf("WrapCtx() errors.Is(context.Canceled) = false, want true")
+	}
+	if len(e.parents) != 1 {
+		t.Errorf("WrapCtx() parents = %d, the context error should not be added twice", len(e.parents))
+	}
+
+	e = WrapCtx(ctx, Wrap(ctx.Err()))
+	if len(e.parents) != 1 {
+		t.Errorf("WrapCtx() parents = %d, the context error should not be added twice when it's wrapped", len(e.parents))
+	}
+
+	e = NewCtx(ctx)
+	if !errors.Is(e, context.Canceled) || !errors.Is(e, CanceledErr) {
+		t.Errorf("NewCtx() did not wrap the context error, got %v", e)
+	}
+}
@@ -4,10 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
-	"strings"
-	"time"
+	"reflect"
+	"sync"
 
 	"github.com/bjatkin/bear/pkg/metrics"
 )
@@ -16,33 +15,31 @@ var (
 	PanicErr = NewType("Panic Error")
 )
 
-// stackFrame is a stack frame in the codes execution
-type stackFrame struct {
-	filename string
-	line     int
-}
-
-func (f stackFrame) String() string {
-	dir, err := os.Getwd()
-	if err != nil {
-		panic("failed to get current working dir: " + err.Error())
-	}
-	return fmt.Sprintf("%s:%d", strings.TrimPrefix(f.filename, dir+"/"), f.line)
-}
-
 // Error is a custom bear error
 type Error struct {
-	id       string
+	id string
+	// idGen generates the id the first time it's needed, see getID
+	idGen    IDGenerator
+	idOnce   sync.Once
 	parents  []error
 	errType  *ErrType
 	tags     map[string]interface{}
 	labels   map[string]struct{}
 	metrics  []*metrics.Metric
-	fmetrics []*metrics.FMetric
 	msg      *string
 	code     *int
 	exitCode *int
-	stack    []stackFrame
+	stack    *stack
+
+	// trace settings
+	traceID string
+	spanID  string
+
+	// stack settings
+	stackDepth StackDepth
+
+	// metric settings
+	metricMode MetricMode
 
 	// fmt settings
 	prettyPrint bool
@@ -51,45 +48,67 @@ type Error struct {
 	noMsg       bool
 	noID        bool
 
+	// mergedMetrics adds the merged metrics to the json output using the mergeOpts
+	mergedMetrics bool
+	mergeOpts     []MetricsOption
+
 	// panic settings
 	stdErr io.Writer
 }
 
 // New creates a new bear.Error
 func New(opts ...ErrOption) *Error {
+	return newError(2, opts)
+}
+
+// newError creates a new bear.Error and counts it if errors are being collected.
+// skip is the number of callers to skip, 1 being the caller of newError
+func newError(skip int, opts []ErrOption) *Error {
+	e := buildError(skip+1, opts)
+	collect(e)
+
+	return e
+}
+
+// joinOpts joins the options into a new slice, appending to the callers slices directly
+// could overwrite options shared by other errors, e.g. the options of a template
+func joinOpts(opts ...[]ErrOption) []ErrOption {
+	size := 0
+	for _, o := range opts {
+		size += len(o)
+	}
+
+	joined := make([]ErrOption, 0, size)
+	for _, o := range opts {
+		joined = append(joined, o...)
+	}
+	return joined
+}
+
+// buildError creates a new bear.Error, the stack is captured after the options are applied
+// so the stack depth can be set by the options. skip is the number of callers to skip, 1 being the caller of buildError
+func buildError(skip int, opts []ErrOption) *Error {
 	e := &Error{
-		id:     newRandomID(),
-		stack:  getStackTrace(2),
-		stdErr: os.Stderr,
+		stackDepth: DefaultStackDepth(),
+		metricMode: DefaultMetricMode(),
+		stdErr:     os.Stderr,
 	}
 	for _, opt := range opts {
 		opt(e)
 	}
+	e.snapshotMetrics()
 
-	return e
-}
-
-func newRandomID() string {
-	randSource := rand.NewSource(time.Now().UnixNano())
-	idLen := 64
-	ref := "abcdef0123456789"
-
-	var id string
-	for i := 0; i < idLen; i++ {
-		id += string(ref[randSource.Int63()%int64(len(ref))])
+	if e.id == "" {
+		e.idGen = newIDGenerator()
 	}
+	e.stack = getStackTrace(skip+1, e.stackDepth)
 
-	return id
+	return e
 }
 
 // Wrap creates a new bear.Error with parent err
 func Wrap(err error, opts ...ErrOption) *Error {
-	e := New(append(opts, WithParent(err))...)
-
-	// reset the stack trace since were calling new from inside the package
-	e.stack = getStackTrace(2)
-
-	return e
+	return newError(2, joinOpts(opts, []ErrOption{WithParent(err)}))
 }
 
 // ErrOption adds optional info to an error
@@ -146,18 +165,100 @@ func WithMetrics(metrics ...*metrics.Metric) ErrOption {
 }
 
 // WithFMetric adds new fmetrics to the error
+//
+// Deprecated: use WithMetrics with metrics.NewFloatMetric instead
 func WithFMetric(metrics ...*metrics.FMetric) ErrOption {
 	return func(e *Error) {
-		e.fmetrics = append(e.fmetrics, metrics...)
+		for _, m := range metrics {
+			e.metrics = append(e.metrics, &m.Metric)
+		}
 	}
 }
 
 // ErrType gives the error a general error class
 type ErrType string
 
+// Error implements the error interface so error types can be used as targets for errors.Is and errors.As
+func (t ErrType) Error() string {
+	return string(t)
+}
+
+// typeParents tracks the parent types of each error type
+var (
+	typeParents   = make(map[ErrType][]ErrType)
+	typeParentsMu sync.RWMutex
+)
+
 // NewType creates a new error type, these should be broad categories of errors rather than specific
-func NewType(name string) ErrType {
-	return ErrType(name)
+// any parent types passed will make the new type match the parents in Is (e.g. a DBTimeout is a Timeout)
+func NewType(name string, parents ...ErrType) ErrType {
+	t := ErrType(name)
+	if len(parents) == 0 {
+		return t
+	}
+
+	typeParentsMu.Lock()
+	defer typeParentsMu.Unlock()
+	for _, parent := range parents {
+		if !containsType(typeParents[t], parent) {
+			typeParents[t] = append(typeParents[t], parent)
+		}
+	}
+
+	return t
+}
+
+// Parents returns the direct parent types of the error type
+func (t ErrType) Parents() []ErrType {
+	typeParentsMu.RLock()
+	defer typeParentsMu.RUnlock()
+
+	return append([]ErrType(nil), typeParents[t]...)
+}
+
+// IsA returns true if the error type is the target type or if any of its ancestor types are the target type
+func (t ErrType) IsA(target ErrType) bool {
+	if t == target {
+		return true
+	}
+
+	typeParentsMu.RLock()
+	defer typeParentsMu.RUnlock()
+
+	// most types have no parents so there's nothing to walk
+	parents := typeParents[t]
+	if len(parents) == 0 {
+		return false
+	}
+
+	seen := map[ErrType]struct{}{t: {}}
+	check := append([]ErrType(nil), parents...)
+	for len(check) > 0 {
+		next := check[0]
+		check = check[1:]
+		if next == target {
+			return true
+		}
+
+		if _, ok := seen[next]; ok {
+			continue
+		}
+		seen[next] = struct{}{}
+		check = append(check, typeParents[next]...)
+	}
+
+	return false
+}
+
+// containsType returns true if the type is in the list of types
+func containsType(types []ErrType, t ErrType) bool {
+	for _, check := range types {
+		if check == t {
+			return true
+		}
+	}
+
+	return false
 }
 
 // WithType adds an error type to the error
@@ -225,6 +326,7 @@ func (e *Error) Add(opts ...ErrOption) *Error {
 	for _, opt := range opts {
 		opt(e)
 	}
+	e.snapshotMetrics()
 
 	return e
 }
@@ -262,14 +364,134 @@ func (e *Error) GetTag(tag string) (interface{}, bool) {
 
 // GetID returns the ID of the error
 func (e *Error) GetID() string {
-	return e.id
+	return e.getID()
+}
+
+// GetTags returns a copy of all the tags that have been set on the error
+func (e *Error) GetTags() map[string]interface{} {
+	tags := make(map[string]interface{}, len(e.tags))
+	for name, value := range e.tags {
+		tags[name] = value
+	}
+
+	return tags
+}
+
+// GetLabels returns all the labels that have been set on the error in sorted order
+func (e *Error) GetLabels() []string {
+	return mapToArray(e.labels)
+}
+
+// GetErrType returns true and the error type if it has been set on the error
+// otherwise an empty type and false are returned
+func (e *Error) GetErrType() (ErrType, bool) {
+	if e.errType == nil {
+		return "", false
+	}
+
+	return *e.errType, true
+}
+
+// GetMsg returns true and the message if it has been set on the error
+// otherwise an empty string and false are returned
+func (e *Error) GetMsg() (string, bool) {
+	if e.msg == nil {
+		return "", false
+	}
+
+	return *e.msg, true
+}
+
+// GetCode returns true and the code if it has been set on the error
+// otherwise 0 and false are returned
+func (e *Error) GetCode() (int, bool) {
+	if e.code == nil {
+		return 0, false
+	}
+
+	return *e.code, true
+}
+
+// GetExitCode returns true and the exit code if it has been set on the error
+// otherwise 0 and false are returned
+func (e *Error) GetExitCode() (int, bool) {
+	if e.exitCode == nil {
+		return 0, false
+	}
+
+	return *e.exitCode, true
+}
+
+// GetStack returns the stack trace of the error, each frame is in the file:line format
+func (e *Error) GetStack() []string {
+	var stack []string
+	for _, frame := range e.stack.Frames() {
+		stack = append(stack, frame.String())
+	}
+
+	return stack
+}
+
+// GetFrames returns the stack trace of the error with the function and package of each frame
+func (e *Error) GetFrames() []Frame {
+	var frames []Frame
+	for _, frame := range e.stack.Frames() {
+		frames = append(frames, Frame{
+			File:     frame.filename,
+			Line:     frame.line,
+			Function: frame.function,
+			Package:  frame.pkg,
+		})
+	}
+
+	return frames
+}
+
+// Unwrap returns the parents of the error, this lets errors.Is and errors.As walk the full error tree
+func (e *Error) Unwrap() []error {
+	return e.parents
+}
+
+// Is implements the interface used by errors.Is
+// it returns true if target is this error, the error's type (or one of its parent types),
+// or a bear error with a matching error type
+func (e *Error) Is(target error) bool {
+	switch v := target.(type) {
+	case ErrType:
+		return e.errType != nil && e.errType.IsA(v)
+	case *Error:
+		if v == e {
+			return true
+		}
+		return v.errType != nil && e.errType != nil && e.errType.IsA(*v.errType)
+	}
+
+	return false
+}
+
+// As implements the interface used by errors.As
+// if target is an *ErrType and the error has a type it will be set on target
+func (e *Error) As(target interface{}) bool {
+	switch v := target.(type) {
+	case *ErrType:
+		if e.errType == nil {
+			return false
+		}
+		*v = *e.errType
+		return true
+	}
+
+	return false
 }
 
 // WrapPanic should be used as a defer function, it will catch any panics inside the function as wrap them as a parent error
 // the provided opts are used to create the new panic error
 func (e *Error) WrapPanic(opts ...ErrOption) {
 	if err := recover(); err != nil {
-		parent := New(opts...).Add(WithErrType(PanicErr))
+		parent := New(joinOpts(opts, []ErrOption{WithErrType(PanicErr)})...)
+
+		// reset the stack trace so it starts at the panic rather than inside the runtime
+		parent.stack = getPanicStack(2, parent.stackDepth)
 
 		switch v := err.(type) {
 		// all the int types
@@ -299,6 +521,9 @@ func (e *Error) WrapPanic(opts ...ErrOption) {
 		case string:
 			parent.Add(WithMsg(v))
 
+		case error:
+			parent.Add(WithParent(v))
+
 		default:
 			parent.Add(WithTag("value", v), WithTag("type", fmt.Sprintf("%T", v)))
 		}
@@ -322,16 +547,101 @@ func AsBerr(e error) (*Error, bool) {
 		return berr, ok
 	}
 
-	return New(WithMsg(e.Error())), true
+	// converted errors are not counted by the collector since they're not new errors
+	return buildError(2, []ErrOption{WithMsg(e.Error())}), false
 }
 
-// Is returns true if the error is of the same type as the error provided
-// If e is a bear.Error the errType field will be checked
+// Is returns true if any error in the tree of e is of the error type provided
+// For bear errors the errType field (and its parent types) will be checked
 // otherwise the e.Error() method will be check to see if it matches the error type
 func Is(e error, t ErrType) bool {
-	if berr, ok := AsBerr(e); ok {
-		return berr.errType != nil && *berr.errType == t
+	if e == nil {
+		return false
+	}
+
+	if berr, ok := e.(*Error); ok {
+		if berr.errType != nil && berr.errType.IsA(t) {
+			return true
+		}
+	} else if e.Error() == string(t) {
+		return true
 	}
 
-	return e.Error() == string(t)
+	for _, parent := range Unwrap(e) {
+		if Is(parent, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindType returns the first bear error in the tree of e that is of the error type provided
+// the tree is searched depth first, starting with e. If no error is found nil is returned
+func FindType(e error, t ErrType) *Error {
+	if e == nil {
+		return nil
+	}
+
+	if berr, ok := e.(*Error); ok && berr.errType != nil && berr.errType.IsA(t) {
+		return berr
+	}
+
+	for _, parent := range Unwrap(e) {
+		if found := FindType(parent, t); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// Unwrap returns the direct parents of the given error
+// it supports both the Unwrap() error and Unwrap() []error methods so it can be used with any version of go
+func Unwrap(e error) []error {
+	switch v := e.(type) {
+	case interface{ Unwrap() []error }:
+		return v.Unwrap()
+	case interface{ Unwrap() error }:
+		if parent := v.Unwrap(); parent != nil {
+			return []error{parent}
+		}
+	}
+
+	return nil
+}
+
+// IsAny returns true if any error in the tree of e matches any of the targets
+// errors are matched by equality or by an Is(error) bool method, the tree is walked using Unwrap
+func IsAny(e error, targets ...error) bool {
+	if e == nil {
+		return false
+	}
+
+	for _, target := range targets {
+		if isErr(e, target) {
+			return true
+		}
+	}
+
+	for _, parent := range Unwrap(e) {
+		if IsAny(parent, targets...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isErr checks a single error against the target without walking the error tree
+func isErr(e, target error) bool {
+	if target != nil && reflect.TypeOf(target).Comparable() && e == target {
+		return true
+	}
+
+	if is, ok := e.(interface{ Is(error) bool }); ok {
+		return is.Is(target)
+	}
+
+	return false
 }
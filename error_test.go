@@ -2,9 +2,13 @@ package bear
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/bjatkin/bear/pkg/metrics"
@@ -64,6 +68,29 @@ func TestNew(t *testing.T) {
 			nil,
 			`{"metrics":[{"name":"test","value":0},{"name":"again","value":0}]}`,
 		},
+		{
+			"with int and float metrics",
+			args{
+				opts: append(defaultOpts, WithMetrics(metrics.NewMetric("int")), WithFMetric(metrics.NewFMetric("legacy")), WithMetrics(metrics.NewFloatMetric("float"))),
+			},
+			func(e *Error) {
+				for _, m := range e.metrics {
+					m.AddFloat(1.5)
+				}
+			},
+			`{"metrics":[{"name":"int","value":1},{"name":"legacy","value":1.5,"float":true},{"name":"float","value":1.5,"float":true}]}`,
+		},
+		{
+			"with histogram",
+			args{
+				opts: append(defaultOpts, WithMetrics(metrics.NewHistogram("latency", 1), metrics.StartTimer("timer").Stop())),
+			},
+			func(e *Error) {
+				e.metrics[0].Observe(0.5)
+				e.metrics[1].SetFloat(0.25)
+			},
+			`{"metrics":[{"name":"latency","value":0.5,"count":1,"buckets":[{"le":1,"count":1}]},{"name":"timer","value":0.25,"float":true}]}`,
+		},
 		{
 			"with error type",
 			args{
@@ -445,3 +472,416 @@ func TestError_GetTag(t *testing.T) {
 		})
 	}
 }
+
+func TestError_Unwrap(t *testing.T) {
+	parentA := errors.New("parent a")
+	parentB := New(WithCode(1))
+
+	tests := []struct {
+		name string
+		err  *Error
+		want []error
+	}{
+		{
+			"no parents",
+			New(),
+			nil,
+		},
+		{
+			"single parent",
+			Wrap(parentA),
+			[]error{parentA},
+		},
+		{
+			"multiple parents",
+			New(WithParent(parentA), WithParent(parentB)),
+			[]error{parentA, parentB},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Unwrap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Error.Unwrap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	sentinel := New(WithErrType(NewType("sentinel")))
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			"wrapped std error",
+			Wrap(io.EOF),
+			io.EOF,
+			true,
+		},
+		{
+			"deeply wrapped std error",
+			Wrap(Wrap(fmt.Errorf("read: %w", io.EOF))),
+			io.EOF,
+			true,
+		},
+		{
+			"second parent",
+			New(WithParent(io.ErrClosedPipe), WithParent(io.EOF)),
+			io.EOF,
+			true,
+		},
+		{
+			"missing error",
+			New(WithParent(io.ErrClosedPipe)),
+			io.EOF,
+			false,
+		},
+		{
+			"same error type",
+			Wrap(io.EOF, WithErrType(NewType("sentinel"))),
+			sentinel,
+			true,
+		},
+		{
+			"error type target",
+			Wrap(Wrap(io.EOF, WithErrType(NewType("sentinel")))),
+			NewType("sentinel"),
+			true,
+		},
+		{
+			"different error type",
+			Wrap(io.EOF, WithErrType(NewType("other"))),
+			sentinel,
+			false,
+		},
+		{
+			"wrapped panic",
+			func() (e *Error) {
+				e = New()
+				defer e.WrapPanic()
+				panic(io.EOF)
+			}(),
+			io.EOF,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+			if got := IsAny(tt.err, tt.target); got != tt.want {
+				t.Errorf("IsAny() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	inner := New(WithCode(1))
+	err := fmt.Errorf("outer: %w", Wrap(io.EOF, WithParent(inner)))
+
+	var berr *Error
+	if !errors.As(err, &berr) {
+		t.Fatalf("errors.As() could not find bear error")
+	}
+	if !reflect.DeepEqual(berr.Unwrap(), []error{inner, io.EOF}) {
+		t.Errorf("errors.As() found the wrong bear error %v", berr)
+	}
+
+	var errType ErrType
+	if errors.As(err, &errType) {
+		t.Errorf("errors.As() found error type %s, want none", errType)
+	}
+
+	err = Wrap(err, WithErrType(NewType("found")))
+	if !errors.As(err, &errType) {
+		t.Fatalf("errors.As() could not find error type")
+	}
+	if errType != NewType("found") {
+		t.Errorf("errors.As() error type = %s, want found", errType)
+	}
+}
+
+func TestIsAny(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		targets []error
+		want    bool
+	}{
+		{
+			"nil error",
+			nil,
+			[]error{io.EOF},
+			false,
+		},
+		{
+			"no targets",
+			Wrap(io.EOF),
+			nil,
+			false,
+		},
+		{
+			"one of many targets",
+			Wrap(io.EOF),
+			[]error{io.ErrClosedPipe, io.EOF},
+			true,
+		},
+		{
+			"no matching targets",
+			Wrap(io.EOF),
+			[]error{io.ErrClosedPipe, io.ErrShortWrite},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAny(tt.err, tt.targets...); got != tt.want {
+				t.Errorf("IsAny() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsBerr(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantMsg string
+		wantOK  bool
+	}{
+		{
+			"bear error",
+			New(WithMsg("bear")),
+			`{"msg":"bear"}`,
+			true,
+		},
+		{
+			"std error",
+			errors.New("std"),
+			`{"msg":"std"}`,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotOK := AsBerr(tt.err)
+			if gotOK != tt.wantOK {
+				t.Errorf("AsBerr() gotOK = %v, wantOK %v", gotOK, tt.wantOK)
+			}
+
+			got.Add(FmtNoStack(true), FmtNoID(true))
+			if got.Error() != tt.wantMsg {
+				t.Errorf("AsBerr() got = %s, want %s", got.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestIs(t *testing.T) {
+	timeout := NewType("Timeout")
+	dbTimeout := NewType("DB Timeout", timeout)
+	notFound := NewType("Not Found")
+
+	tests := []struct {
+		name    string
+		err     error
+		errType ErrType
+		want    bool
+	}{
+		{
+			"nil error",
+			nil,
+			timeout,
+			false,
+		},
+		{
+			"top level type",
+			New(WithErrType(timeout)),
+			timeout,
+			true,
+		},
+		{
+			"parent type",
+			New(WithErrType(dbTimeout)),
+			timeout,
+			true,
+		},
+		{
+			"child type",
+			New(WithErrType(timeout)),
+			dbTimeout,
+			false,
+		},
+		{
+			"type in parent error",
+			Wrap(fmt.Errorf("query: %w", New(WithErrType(dbTimeout))), WithErrType(notFound)),
+			timeout,
+			true,
+		},
+		{
+			"missing type",
+			Wrap(New(WithErrType(notFound))),
+			timeout,
+			false,
+		},
+		{
+			"std error string",
+			Wrap(errors.New("Timeout")),
+			timeout,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Is(tt.err, tt.errType); got != tt.want {
+				t.Errorf("Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindType(t *testing.T) {
+	timeout := NewType("Timeout")
+	dbTimeout := NewType("DB Timeout", timeout)
+
+	match := New(WithErrType(dbTimeout), WithTag("table", "users"))
+	err := Wrap(
+		New(WithParent(io.EOF), WithParent(match)),
+		WithErrType(NewType("Request Failed")),
+	)
+
+	got := FindType(err, timeout)
+	if got != match {
+		t.Fatalf("FindType() = %v, want %v", got, match)
+	}
+	if table, _ := got.GetTag("table"); table != "users" {
+		t.Errorf("FindType() table tag = %v, want users", table)
+	}
+
+	if got := FindType(err, NewType("Not Found")); got != nil {
+		t.Errorf("FindType() = %v, want nil", got)
+	}
+}
+
+func TestErrType_IsA(t *testing.T) {
+	base := NewType("base")
+	middle := NewType("middle", base)
+	other := NewType("other")
+	leaf := NewType("leaf", middle, other)
+
+	// cycles in the type hierarchy should not cause IsA to loop forever
+	cycleA := NewType("cycle a")
+	cycleB := NewType("cycle b", cycleA)
+	NewType("cycle a", cycleB)
+
+	tests := []struct {
+		name   string
+		t      ErrType
+		target ErrType
+		want   bool
+	}{
+		{"same type", base, base, true},
+		{"direct parent", middle, base, true},
+		{"grand parent", leaf, base, true},
+		{"second parent", leaf, other, true},
+		{"child", base, leaf, false},
+		{"unrelated", other, base, false},
+		{"cycle", cycleA, NewType("missing"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.IsA(tt.target); got != tt.want {
+				t.Errorf("ErrType.IsA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_ConcurrentMetrics(t *testing.T) {
+	counter := metrics.NewMetric("counter")
+	latency := metrics.NewFloatMetric("latency")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				counter.Incr()
+				latency.AddFloat(0.25)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			e := Wrap(New(WithMetrics(counter)), WithMetrics(counter, latency))
+			for j := 0; j < 100; j++ {
+				_ = e.Error()
+				_ = fmt.Sprintf("%+v", e)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := counter.GetValue(); got != 2000 {
+		t.Errorf("counter = %d, want 2000", got)
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New()
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Wrap(io.EOF)
+	}
+}
+
+func BenchmarkTemplate_New(b *testing.B) {
+	tmpl := NewTemplate(WithCode(500), WithLabels("db"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = tmpl.New()
+	}
+}
+
+// TestAllocs guards against regressions in the number of allocations needed to create an error.
+// Errors are often created and immediately discarded, e.g. by errors.Is checks, so they should stay cheap
+func TestAllocs(t *testing.T) {
+	tmpl := NewTemplate(WithCode(500), WithLabels("db"))
+	base := NewType("alloc base")
+
+	tests := []struct {
+		name string
+		fn   func()
+		want float64
+	}{
+		// the error, the stack and the stack's program counters
+		{"New", func() { _ = New() }, 3},
+		{"New without stack", func() { _ = New(WithStackDepth(StackNone)) }, 1},
+		// plus the options and the parents
+		{"Wrap", func() { _ = Wrap(io.EOF) }, 5},
+		// plus the options and the labels
+		{"Template.New", func() { _ = tmpl.New() }, 5},
+		// types without parents don't need to walk the type hierarchy
+		{"ErrType.IsA same type", func() { _ = base.IsA(base) }, 0},
+		{"ErrType.IsA no parents", func() { _ = base.IsA(PanicErr) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testing.AllocsPerRun(100, tt.fn); got > tt.want {
+				t.Errorf("%s allocations = %v, want at most %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package bear
 
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
 // FmtPrettyPrint tells the error to format its Error()
 func FmtPrettyPrint(on bool) ErrOption {
 	return func(e *Error) {
@@ -34,3 +41,149 @@ func FmtNoID(on bool) ErrOption {
 		e.noID = on
 	}
 }
+
+// FmtMergedMetrics adds the merged metrics of the error and all its parents to the json output, see Error.Metrics
+func FmtMergedMetrics(on bool, opts ...MetricsOption) ErrOption {
+	return func(e *Error) {
+		e.mergedMetrics = on
+		e.mergeOpts = opts
+	}
+}
+
+// fmtSettings are the format settings of an error, settings are passed down to parent errors
+type fmtSettings struct {
+	noStack   bool
+	noParents bool
+	noMsg     bool
+	noID      bool
+}
+
+// fmtSettings returns the errors format settings merged with any inherited settings
+func (e *Error) fmtSettings(inherited fmtSettings) fmtSettings {
+	return fmtSettings{
+		noStack:   e.noStack || inherited.noStack,
+		noParents: e.noParents || inherited.noParents,
+		noMsg:     e.noMsg || inherited.noMsg,
+		noID:      e.noID || inherited.noID,
+	}
+}
+
+// Format implements the fmt.Formatter interface
+// %v and %s print a compact single line message, %q prints the same message quoted
+// %+v prints a multi-line tree of the error and its parents including the stack traces
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			b := &strings.Builder{}
+			writeTree(b, e, fmtSettings{}, "")
+			_, _ = io.WriteString(s, b.String())
+			return
+		}
+		_, _ = io.WriteString(s, e.compact(fmtSettings{}))
+	case 's':
+		_, _ = io.WriteString(s, e.compact(fmtSettings{}))
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.compact(fmtSettings{}))
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(*bear.Error=%s)", verb, e.compact(fmtSettings{}))
+	}
+}
+
+// compact returns a single line message for the error and its parents
+func (e *Error) compact(inherited fmtSettings) string {
+	settings := e.fmtSettings(inherited)
+	msg := e.headline(settings)
+
+	if settings.noParents || len(e.parents) == 0 {
+		return msg
+	}
+
+	var parents []string
+	for _, parent := range e.parents {
+		if berr, ok := parent.(*Error); ok {
+			parents = append(parents, berr.compact(settings))
+			continue
+		}
+		parents = append(parents, parent.Error())
+	}
+
+	if len(parents) == 1 {
+		return msg + ": " + parents[0]
+	}
+	return msg + ": [" + strings.Join(parents, "; ") + "]"
+}
+
+// headline returns the type, message, codes and tags of the error on a single line
+func (e *Error) headline(settings fmtSettings) string {
+	var parts []string
+	if e.errType != nil {
+		parts = append(parts, "["+string(*e.errType)+"]")
+	}
+	if e.msg != nil && !settings.noMsg {
+		parts = append(parts, *e.msg)
+	}
+	if e.code != nil {
+		parts = append(parts, fmt.Sprintf("code=%d", *e.code))
+	}
+	if e.exitCode != nil {
+		parts = append(parts, fmt.Sprintf("exitCode=%d", *e.exitCode))
+	}
+
+	var tags []string
+	for tag := range e.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		parts = append(parts, fmt.Sprintf("%s=%v", tag, e.tags[tag]))
+	}
+
+	if len(parts) == 0 {
+		if settings.noID {
+			return "bear error"
+		}
+		return "bear error " + e.getID()
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// writeTree writes a multi-line description of the error and its parents to the builder
+func writeTree(b *strings.Builder, e *Error, inherited fmtSettings, indent string) {
+	settings := e.fmtSettings(inherited)
+	b.WriteString(e.headline(settings))
+
+	if !settings.noID {
+		b.WriteString("\n" + indent + "id: " + e.getID())
+	}
+	if e.traceID != "" {
+		b.WriteString("\n" + indent + "trace: " + e.traceID + " span: " + e.spanID)
+	}
+	if len(e.labels) > 0 {
+		b.WriteString("\n" + indent + "labels: " + strings.Join(mapToArray(e.labels), ", "))
+	}
+	for _, m := range e.metrics {
+		b.WriteString("\n" + indent + "metric: " + m.String())
+	}
+
+	if !settings.noStack {
+		for _, frame := range e.stack.Frames() {
+			b.WriteString("\n" + indent + frame.function)
+			b.WriteString("\n" + indent + "\t" + frame.String())
+		}
+	}
+
+	if settings.noParents {
+		return
+	}
+
+	for _, parent := range e.parents {
+		b.WriteString("\n" + indent + "caused by: ")
+		if berr, ok := parent.(*Error); ok {
+			writeTree(b, berr, settings, indent+"    ")
+			continue
+		}
+		b.WriteString(parent.Error())
+	}
+}
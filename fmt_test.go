@@ -1,6 +1,8 @@
 package bear
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -50,11 +52,11 @@ func TestNewFmt(t *testing.T) {
 				opts: []ErrOption{FmtNoID(true)},
 			},
 			func(e *Error) {
-				e.stack = []stackFrame{
+				e.stack = &stack{frames: []stackFrame{
 					{filename: "test.go", line: 100},
 					{filename: "test2.go", line: 50},
 					{filename: "final.go", line: 1},
-				}
+				}}
 			},
 			`{"stack":["test.go:100","test2.go:50","final.go:1"]}`,
 		},
@@ -92,3 +94,131 @@ func TestNewFmt(t *testing.T) {
 		})
 	}
 }
+
+func TestError_Format(t *testing.T) {
+	defaultOpts := []ErrOption{FmtNoStack(true), FmtNoID(true)}
+	timeout := NewType("Timeout")
+
+	tests := []struct {
+		name   string
+		format string
+		err    *Error
+		setup  func(e *Error)
+		want   string
+	}{
+		{
+			"empty error",
+			"%v",
+			New(defaultOpts...),
+			nil,
+			"bear error",
+		},
+		{
+			"empty error with id",
+			"%v",
+			New(FmtNoStack(true)),
+			func(e *Error) {
+				e.id = "test"
+			},
+			"bear error test",
+		},
+		{
+			"compact",
+			"%v",
+			New(append(defaultOpts, WithErrType(timeout), WithMsg("db failed"), WithCode(504), WithTag("table", "users"), WithTag("attempt", 3))...),
+			nil,
+			"[Timeout] db failed code=504 attempt=3 table=users",
+		},
+		{
+			"compact with parents",
+			"%v",
+			Wrap(New(WithMsg("inner"), WithParent(errors.New("std error"))), append(defaultOpts, WithMsg("outer"))...),
+			nil,
+			"outer: inner: std error",
+		},
+		{
+			"compact with many parents",
+			"%s",
+			New(append(defaultOpts, WithMsg("outer"), WithParent(errors.New("a")), WithParent(New(WithCode(2))))...),
+			nil,
+			"outer: [a; code=2]",
+		},
+		{
+			"compact with no parents",
+			"%s",
+			New(append(defaultOpts, WithMsg("outer"), WithParent(errors.New("a")), FmtNoParents(true))...),
+			nil,
+			"outer",
+		},
+		{
+			"compact with no msg",
+			"%v",
+			New(append(defaultOpts, WithMsg("hidden"), WithCode(1), FmtNoMsg(true), WithParent(New(WithMsg("hidden"))))...),
+			nil,
+			"code=1: bear error",
+		},
+		{
+			"quoted",
+			"%q",
+			New(append(defaultOpts, WithMsg(`say "hi"`))...),
+			nil,
+			`"say \"hi\""`,
+		},
+		{
+			"unknown verb",
+			"%d",
+			New(append(defaultOpts, WithCode(1))...),
+			nil,
+			"%!d(*bear.Error=code=1)",
+		},
+		{
+			"tree",
+			"%+v",
+			New(WithMsg("outer"), WithLabels("b", "a"), WithParent(New(WithMsg("inner"), WithParent(errors.New("std error"))))),
+			func(e *Error) {
+				e.id = "outer-id"
+				e.stack = &stack{frames: []stackFrame{
+					{filename: "main.go", line: 10, function: "main.run"},
+					{filename: "main.go", line: 3, function: "main.main"},
+				}}
+
+				parent := e.parents[0].(*Error)
+				parent.id = "inner-id"
+				parent.stack = &stack{frames: []stackFrame{
+					{filename: "db.go", line: 5, function: "db.Query"},
+				}}
+			},
+			`outer
+id: outer-id
+labels: a, b
+main.run
+	main.go:10
+main.main
+	main.go:3
+caused by: inner
+    id: inner-id
+    db.Query
+    	db.go:5
+    caused by: std error`,
+		},
+		{
+			"tree with fmt options",
+			"%+v",
+			New(append(defaultOpts, WithMsg("outer"), WithParent(New(WithMsg("inner"), WithCode(3))))...),
+			nil,
+			`outer
+caused by: inner code=3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.err)
+			}
+
+			if got := fmt.Sprintf(tt.format, tt.err); got != tt.want {
+				t.Errorf("Error.Format() got \n'%s', want \n'%s'", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package bear
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IDGenerator generates the ids of new errors, implementations must be safe to use from multiple goroutines
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc is a function that can be used as an IDGenerator
+type IDGeneratorFunc func() string
+
+// NewID calls the function to generate a new id
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// HexIDGenerator generates random 64 character hex ids using crypto/rand, it's the default IDGenerator
+type HexIDGenerator struct{}
+
+// NewID generates a new random hex id
+func (HexIDGenerator) NewID() string {
+	var b [32]byte
+	randomBytes(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// SortableIDGenerator generates UUIDv7 style ids that sort by the time they were created.
+// Ids created in the same millisecond are still sorted by the order they were created in.
+// When it's used for errors the timestamp is taken when the error is created, even though the id is formatted later
+type SortableIDGenerator struct {
+	mu     sync.Mutex
+	lastMS uint64
+	seq    uint16
+	// now is used to get the current time, it's only replaced in tests
+	now func() time.Time
+}
+
+// NewSortableIDGenerator creates a new SortableIDGenerator
+func NewSortableIDGenerator() *SortableIDGenerator {
+	return &SortableIDGenerator{now: time.Now}
+}
+
+// NewID generates a new time sortable id, e.g. 01890a5d-ac96-7000-9c1e-2f4f8a5b3c7d
+func (g *SortableIDGenerator) NewID() string {
+	return g.reserveID().NewID()
+}
+
+// reserveID takes the timestamp and sequence number for the id now so it sorts by creation time
+func (g *SortableIDGenerator) reserveID() IDGenerator {
+	ms, seq := g.next()
+	return sortableID{ms: ms, seq: seq}
+}
+
+// sortableID is a reserved SortableIDGenerator id that has not been formatted yet
+type sortableID struct {
+	ms  uint64
+	seq uint16
+}
+
+// NewID formats the reserved id
+func (r sortableID) NewID() string {
+	var b [16]byte
+	randomBytes(b[8:])
+
+	// 48 bits of unix milliseconds, 4 bits of version, 12 bits of sequence
+	binary.BigEndian.PutUint64(b[:8], r.ms<<16|0x7000|uint64(r.seq))
+	// 2 bits of variant and 62 random bits
+	b[8] = b[8]&0x3f | 0x80
+
+	var id [36]byte
+	hex.Encode(id[0:8], b[0:4])
+	id[8] = '-'
+	hex.Encode(id[9:13], b[4:6])
+	id[13] = '-'
+	hex.Encode(id[14:18], b[6:8])
+	id[18] = '-'
+	hex.Encode(id[19:23], b[8:10])
+	id[23] = '-'
+	hex.Encode(id[24:], b[10:])
+
+	return string(id[:])
+}
+
+// next returns the timestamp and sequence number for the next id, the sequence is reset every millisecond
+// and if it overflows the timestamp is moved forward so the ids are always increasing
+func (g *SortableIDGenerator) next() (uint64, uint16) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now
+	if g.now != nil {
+		now = g.now
+	}
+
+	ms := uint64(now().UnixMilli())
+	switch {
+	case ms > g.lastMS:
+		g.lastMS = ms
+		g.seq = 0
+	case g.seq < 0xfff:
+		g.seq++
+	default:
+		g.lastMS++
+		g.seq = 0
+	}
+
+	return g.lastMS, g.seq
+}
+
+// SequentialIDGenerator generates deterministic ids made of a prefix and a counter e.g. test-1, test-2.
+// It's meant to be used in tests where the error ids need to be predictable.
+// When it's used for errors the counter is taken when the error is created, so ids follow the creation order
+type SequentialIDGenerator struct {
+	prefix string
+	count  uint64
+}
+
+// NewSequentialIDGenerator creates a new SequentialIDGenerator, the prefix is added to the start of each id
+func NewSequentialIDGenerator(prefix string) *SequentialIDGenerator {
+	return &SequentialIDGenerator{prefix: prefix}
+}
+
+// NewID generates the next id in the sequence
+func (g *SequentialIDGenerator) NewID() string {
+	return g.reserveID().NewID()
+}
+
+// reserveID takes the next number in the sequence now so the id follows the order errors are created in
+func (g *SequentialIDGenerator) reserveID() IDGenerator {
+	return sequentialID{prefix: g.prefix, n: atomic.AddUint64(&g.count, 1)}
+}
+
+// sequentialID is a reserved SequentialIDGenerator id that has not been formatted yet
+type sequentialID struct {
+	prefix string
+	n      uint64
+}
+
+// NewID formats the reserved id
+func (r sequentialID) NewID() string {
+	return r.prefix + strconv.FormatUint(r.n, 10)
+}
+
+// Reset starts the sequence over again
+func (g *SequentialIDGenerator) Reset() {
+	atomic.StoreUint64(&g.count, 0)
+}
+
+// idReserver is implemented by generators whose ids depend on the order errors are created in.
+// reserveID is called when the error is created and the IDGenerator it returns formats the id once it's needed
+type idReserver interface {
+	reserveID() IDGenerator
+}
+
+// newIDGenerator returns the IDGenerator a new error uses to create its id
+func newIDGenerator() IDGenerator {
+	g := GetIDGenerator()
+	if r, ok := g.(idReserver); ok {
+		return r.reserveID()
+	}
+	return g
+}
+
+// idGenerator holds the IDGenerator used for new errors
+type idGenerator struct {
+	IDGenerator
+}
+
+// currentIDGenerator is the generator used by errors that don't set WithID
+var currentIDGenerator atomic.Value
+
+// SetIDGenerator sets the IDGenerator used by all errors that don't set WithID,
+// setting the generator to nil restores the default HexIDGenerator
+func SetIDGenerator(g IDGenerator) {
+	if g == nil {
+		g = HexIDGenerator{}
+	}
+	currentIDGenerator.Store(idGenerator{g})
+}
+
+// GetIDGenerator returns the IDGenerator used by all errors that don't set WithID
+func GetIDGenerator() IDGenerator {
+	if g, ok := currentIDGenerator.Load().(idGenerator); ok {
+		return g.IDGenerator
+	}
+	return HexIDGenerator{}
+}
+
+// WithID sets the id of the error rather than generating a new one
+func WithID(id string) ErrOption {
+	return func(e *Error) {
+		e.id = id
+	}
+}
+
+// getID returns the id of the error, the id is only generated the first time it's needed
+// so errors that are never logged or returned don't pay the cost of generating an id
+func (e *Error) getID() string {
+	e.idOnce.Do(func() {
+		if e.id == "" && e.idGen != nil {
+			e.id = e.idGen.NewID()
+		}
+		e.idGen = nil
+	})
+
+	return e.id
+}
+
+// fallbackCount makes sure the fallback bytes are unique if crypto/rand fails
+var fallbackCount uint64
+
+// randomBytes fills b with random bytes from crypto/rand. crypto/rand should never fail,
+// but if it does the bytes are filled using the current time and a counter so ids are still unique
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err == nil {
+		return
+	}
+
+	var fallback [16]byte
+	binary.BigEndian.PutUint64(fallback[:8], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(fallback[8:], atomic.AddUint64(&fallbackCount, 1))
+	for i := range b {
+		b[i] = fallback[i%len(fallback)]
+	}
+}
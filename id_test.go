@@ -0,0 +1,209 @@
+package bear
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator IDGenerator
+		want      *regexp.Regexp
+	}{
+		{
+			"hex",
+			HexIDGenerator{},
+			regexp.MustCompile(`^[0-9a-f]{64}$`),
+		},
+		{
+			"sortable",
+			NewSortableIDGenerator(),
+			regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		},
+		{
+			"sequential",
+			NewSequentialIDGenerator("test-"),
+			regexp.MustCompile(`^test-[0-9]+$`),
+		},
+		{
+			"func",
+			IDGeneratorFunc(func() string { return "static" }),
+			regexp.MustCompile(`^static$`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if id := tt.generator.NewID(); !tt.want.MatchString(id) {
+					t.Fatalf("IDGenerator.NewID() = %s, want it to match %s", id, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHexIDGenerator_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				id := HexIDGenerator{}.NewID()
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("HexIDGenerator.NewID() generated a duplicate id %s", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSortableIDGenerator_Sorted(t *testing.T) {
+	g := NewSortableIDGenerator()
+	now := time.UnixMilli(1700000000000)
+	g.now = func() time.Time { return now }
+
+	var ids []string
+	for i := 0; i < 5000; i++ {
+		// the clock moving backwards should not break the sort order
+		if i == 2500 {
+			now = now.Add(-time.Second)
+		}
+		ids = append(ids, g.NewID())
+	}
+
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("SortableIDGenerator.NewID() ids are not sorted")
+	}
+	if ids[0][:13] != "018bcfe5-6800" {
+		t.Errorf("SortableIDGenerator.NewID() = %s, want the timestamp 018bcfe5-6800", ids[0])
+	}
+}
+
+func TestSequentialIDGenerator(t *testing.T) {
+	g := NewSequentialIDGenerator("err-")
+	if got := g.NewID() + "," + g.NewID(); got != "err-1,err-2" {
+		t.Errorf("SequentialIDGenerator.NewID() = %s, want err-1,err-2", got)
+	}
+
+	g.Reset()
+	if got := g.NewID(); got != "err-1" {
+		t.Errorf("SequentialIDGenerator.Reset() next id = %s, want err-1", got)
+	}
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	defer SetIDGenerator(GetIDGenerator())
+
+	SetIDGenerator(NewSequentialIDGenerator("test-"))
+	tmpl := NewTemplate()
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{"new", New(), "test-1"},
+		{"wrap", Wrap(New(WithID("parent"))), "test-2"},
+		{"template", tmpl.New(), "test-3"},
+		{"with id", New(WithID("custom")), "custom"},
+		{"template with id", tmpl.New(WithID("custom")), "custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.GetID(); got != tt.want {
+				t.Errorf("GetID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	SetIDGenerator(nil)
+	if _, ok := GetIDGenerator().(HexIDGenerator); !ok {
+		t.Errorf("SetIDGenerator(nil) did not restore the default generator")
+	}
+}
+
+func TestError_IDCreationOrder(t *testing.T) {
+	defer SetIDGenerator(GetIDGenerator())
+
+	sortable := NewSortableIDGenerator()
+	now := time.UnixMilli(1700000000000)
+	sortable.now = func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		gen  IDGenerator
+		tick func()
+	}{
+		{"sortable", sortable, func() { now = now.Add(time.Millisecond) }},
+		{"sequential", NewSequentialIDGenerator("test-"), func() {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetIDGenerator(tt.gen)
+
+			e1 := New()
+			tt.tick()
+			e2 := New()
+
+			// reading the ids in the opposite order should not change the order they were created in
+			id2 := e2.GetID()
+			id1 := e1.GetID()
+			if id1 >= id2 {
+				t.Errorf("GetID() e1 = %s, e2 = %s, want e1 to sort before e2", id1, id2)
+			}
+		})
+	}
+}
+
+func TestError_LazyID(t *testing.T) {
+	defer SetIDGenerator(GetIDGenerator())
+
+	var calls int64
+	SetIDGenerator(IDGeneratorFunc(func() string {
+		return "lazy-" + strconv.FormatInt(atomic.AddInt64(&calls, 1), 10)
+	}))
+
+	// std error parents get a new id each time they are converted, so they are not printed
+	e := Wrap(io.EOF, FmtNoParents(true))
+	if !errors.Is(e, io.EOF) || IsAny(e, io.ErrUnexpectedEOF) {
+		t.Fatalf("errors.Is() did not match the parent")
+	}
+	if got := atomic.LoadInt64(&calls); got != 0 {
+		t.Fatalf("New() generated %d ids before the id was used", got)
+	}
+
+	// the id should only be generated once even when it's used from multiple goroutines
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id := e.GetID(); id != "lazy-1" {
+				t.Errorf("GetID() = %s, want lazy-1", id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !strings.Contains(e.Error(), `"id":"lazy-1"`) {
+		t.Errorf("Error() = %s, want the lazy id", e.Error())
+	}
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Errorf("GetID() generated %d ids, want 1", got)
+	}
+}
@@ -1,7 +1,14 @@
 package bear
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/bjatkin/bear/pkg/metrics"
 )
@@ -9,12 +16,15 @@ import (
 // jsonError mirriors the Error type but it's fields are exported so it can be json marshled
 type jsonError struct {
 	ID       *string                `json:"id,omitempty"`
+	TraceID  string                 `json:"traceId,omitempty"`
+	SpanID   string                 `json:"spanId,omitempty"`
 	Parents  []jsonError            `json:"parents,omitempty"`
 	ErrType  *ErrType               `json:"errType,omitempty"`
 	Tags     map[string]interface{} `json:"tags,omitempty"`
 	Labels   []string               `json:"labels,omitempty"`
 	Metrics  []*metrics.Metric      `json:"metrics,omitempty"`
-	Fmetrics []*metrics.FMetric     `json:"fmetrics,omitempty"`
+	Fmetrics []*metrics.Metric      `json:"fmetrics,omitempty"`      // only used to parse json from older versions
+	Merged   []*metrics.Metric      `json:"mergedMetrics,omitempty"` // only set by FmtMergedMetrics, ignored when parsing
 	Msg      *string                `json:"msg,omitempty"`
 	Code     *int                   `json:"code,omitempty"`
 	ExitCode *int                   `json:"exitCode,omitempty"`
@@ -23,18 +33,24 @@ type jsonError struct {
 
 // newJSONError creates a new jsonError from an Error
 func newJSONError(e *Error) jsonError {
+	id := e.getID()
 	err := jsonError{
-		ID:       &e.id,
+		ID:       &id,
+		TraceID:  e.traceID,
+		SpanID:   e.spanID,
 		ErrType:  e.errType,
 		Tags:     e.tags,
 		Labels:   mapToArray(e.labels),
 		Metrics:  e.metrics,
-		Fmetrics: e.fmetrics,
 		Msg:      e.msg,
 		Code:     e.code,
 		ExitCode: e.exitCode,
 	}
 
+	if e.mergedMetrics {
+		err.Merged = e.Metrics(e.mergeOpts...)
+	}
+
 	if e.noMsg {
 		err.Msg = nil
 	}
@@ -48,7 +64,7 @@ func newJSONError(e *Error) jsonError {
 	}
 
 	if !e.noStack {
-		for _, frame := range e.stack {
+		for _, frame := range e.stack.Frames() {
 			err.Stack = append(err.Stack, frame.String())
 		}
 	}
@@ -90,3 +106,106 @@ func mapToArray(m map[string]struct{}) []string {
 
 	return slice
 }
+
+// MarshalJSON implements the marshaler interface
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONError(e))
+}
+
+// UnmarshalJSON implements the unmarshaler interface, it rebuilds the error and all its parents
+// from the json created by Error() or MarshalJSON(). Numeric tag values are parsed as json.Numbers
+func (e *Error) UnmarshalJSON(raw []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+
+	// use numbers so large int tags don't lose precision as float64s
+	decoder.UseNumber()
+
+	var jerr jsonError
+	if err := decoder.Decode(&jerr); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid bear error json, unexpected data after the error")
+	}
+
+	return e.fromJSONError(jerr)
+}
+
+// ParseJSON parses json created by Error() or MarshalJSON() back into a bear error
+func ParseJSON(raw []byte) (*Error, error) {
+	e := &Error{}
+	if err := e.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// fromJSONError replaces the error with the error in the jsonError
+func (e *Error) fromJSONError(jerr jsonError) error {
+	// the error is rebuilt from scratch so any lazy state, like the id, is reset as well
+	*e = Error{
+		errType:  jerr.ErrType,
+		tags:     jerr.Tags,
+		metrics:  jerr.Metrics,
+		msg:      jerr.Msg,
+		code:     jerr.Code,
+		exitCode: jerr.ExitCode,
+		traceID:  jerr.TraceID,
+		spanID:   jerr.SpanID,
+		stdErr:   os.Stderr,
+	}
+
+	if jerr.ID != nil {
+		e.id = *jerr.ID
+	} else {
+		e.noID = true
+	}
+
+	// older versions of bear stored float metrics separately
+	for _, m := range jerr.Fmetrics {
+		e.metrics = append(e.metrics, metrics.NewFloatMetric(m.GetName()))
+		e.metrics[len(e.metrics)-1].AddFloat(m.GetFloat())
+	}
+
+	for _, label := range jerr.Labels {
+		WithLabels(label)(e)
+	}
+
+	for _, parent := range jerr.Parents {
+		p := &Error{}
+		if err := p.fromJSONError(parent); err != nil {
+			return err
+		}
+		e.parents = append(e.parents, p)
+	}
+
+	if len(jerr.Stack) > 0 {
+		frames := make([]stackFrame, 0, len(jerr.Stack))
+		for _, raw := range jerr.Stack {
+			frame, err := parseStackFrame(raw)
+			if err != nil {
+				return err
+			}
+			frames = append(frames, frame)
+		}
+		e.stack = &stack{frames: frames}
+	}
+
+	return nil
+}
+
+// parseStackFrame parses a stack frame in the file:line format
+func parseStackFrame(raw string) (stackFrame, error) {
+	i := strings.LastIndex(raw, ":")
+	if i < 0 {
+		return stackFrame{}, fmt.Errorf("invalid stack frame %q, expected file:line", raw)
+	}
+
+	line, err := strconv.Atoi(raw[i+1:])
+	if err != nil {
+		return stackFrame{}, fmt.Errorf("invalid stack frame %q, expected file:line", raw)
+	}
+
+	return stackFrame{filename: raw[:i], line: line}, nil
+}
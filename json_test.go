@@ -0,0 +1,185 @@
+package bear
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		check   func(t *testing.T, e *Error)
+		wantErr bool
+	}{
+		{
+			"empty error",
+			`{}`,
+			func(t *testing.T, e *Error) {
+				if e.GetID() != "" {
+					t.Errorf("ParseJSON() id = %s, want empty", e.GetID())
+				}
+			},
+			false,
+		},
+		{
+			"all fields",
+			`{"id":"abc","errType":"Timeout","tags":{"table":"users","attempt":3},"labels":["db","retry"],` +
+				`"metrics":[{"name":"retries","value":2}],"fmetrics":[{"name":"latency","value":1.5}],` +
+				`"msg":"failed","code":504,"exitCode":2,"stack":["main.go:10","/abs/path/db.go:5"]}`,
+			func(t *testing.T, e *Error) {
+				if e.GetID() != "abc" {
+					t.Errorf("ParseJSON() id = %s, want abc", e.GetID())
+				}
+				if !Is(e, NewType("Timeout")) {
+					t.Errorf("ParseJSON() error type was not set")
+				}
+				if table, _ := e.GetTag("table"); table != "users" {
+					t.Errorf("ParseJSON() table tag = %v, want users", table)
+				}
+				if !e.HasLabel("db") || !e.HasLabel("retry") {
+					t.Errorf("ParseJSON() labels = %v, want db and retry", e.labels)
+				}
+				if len(e.metrics) != 2 {
+					t.Fatalf("ParseJSON() metrics = %v, want [retries] 2 and [latency] 1.5", e.metrics)
+				}
+				if e.metrics[0].GetName() != "retries" || e.metrics[0].GetValue() != 2 || e.metrics[0].IsFloat() {
+					t.Errorf("ParseJSON() metric = %v, want [retries] 2", e.metrics[0])
+				}
+				if e.metrics[1].GetName() != "latency" || e.metrics[1].GetFloat() != 1.5 || !e.metrics[1].IsFloat() {
+					t.Errorf("ParseJSON() metric = %v, want [latency] 1.5", e.metrics[1])
+				}
+				if *e.msg != "failed" || *e.code != 504 || *e.exitCode != 2 {
+					t.Errorf("ParseJSON() msg, code, exitCode = %s, %d, %d", *e.msg, *e.code, *e.exitCode)
+				}
+
+				frames := e.stack.Frames()
+				if len(frames) != 2 || frames[1].filename != "/abs/path/db.go" || frames[1].line != 5 {
+					t.Errorf("ParseJSON() stack = %v, want [main.go:10 /abs/path/db.go:5]", frames)
+				}
+			},
+			false,
+		},
+		{
+			"nested parents",
+			`{"id":"outer","parents":[{"id":"inner","parents":[{"msg":"root"}]},{"code":1}]}`,
+			func(t *testing.T, e *Error) {
+				if len(e.parents) != 2 {
+					t.Fatalf("ParseJSON() got %d parents, want 2", len(e.parents))
+				}
+
+				inner := e.parents[0].(*Error)
+				if inner.GetID() != "inner" {
+					t.Errorf("ParseJSON() parent id = %s, want inner", inner.GetID())
+				}
+				if root := inner.parents[0].(*Error); *root.msg != "root" {
+					t.Errorf("ParseJSON() root msg = %s, want root", *root.msg)
+				}
+			},
+			false,
+		},
+		{
+			"invalid json",
+			`{"id":`,
+			nil,
+			true,
+		},
+		{
+			"trailing data",
+			`{"id":"abc"}{"id":"def"}`,
+			nil,
+			true,
+		},
+		{
+			"invalid stack frame",
+			`{"stack":["main.go"]}`,
+			nil,
+			true,
+		},
+		{
+			"invalid metric",
+			`{"metrics":[{"name":"retries","value":"two"}]}`,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestError_UnmarshalJSON(t *testing.T) {
+	want := New(
+		WithErrType(NewType("Timeout")),
+		WithMsg("outer"),
+		WithTag("big", int64(math.MaxInt64)),
+		WithLabels("db"),
+		WithMetrics(metrics.NewMetric("retries")),
+		WithParent(New(WithMsg("inner"))),
+	)
+
+	raw, err := json.Marshal(struct {
+		Err *Error `json:"err"`
+	}{want})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var got struct {
+		Err *Error `json:"err"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if got.Err.Error() != want.Error() {
+		t.Errorf("Error.UnmarshalJSON() got \n%s, want \n%s", got.Err.Error(), want.Error())
+	}
+}
+
+func FuzzJSONRoundTrip(f *testing.F) {
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"id":"abc","errType":"Timeout","tags":{"a":1,"b":"c","d":[1.5,true,null]},"labels":["x","y"]}`))
+	f.Add([]byte(`{"metrics":[{"name":"m","value":1}],"fmetrics":[{"name":"f","value":1.25}],"code":1,"exitCode":2}`))
+	f.Add([]byte(`{"metrics":[{"name":"h","value":1.5,"count":2,"buckets":[{"le":1,"count":1}]}]}`))
+	f.Add([]byte(`{"msg":"outer","parents":[{"msg":"inner","stack":["main.go:1"]},{"parents":[{}]}]}`))
+	f.Add([]byte(New(WithParent(New(WithCode(1))), WithTag("big", uint64(math.MaxUint64))).Error()))
+	f.Add([]byte(New(WithMetrics(metrics.NewHistogram("h", math.Inf(1)))).Error()))
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		e, err := ParseJSON(raw)
+		if err != nil {
+			return
+		}
+
+		first, err := e.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON() error = %v", err)
+		}
+
+		parsed, err := ParseJSON(first)
+		if err != nil {
+			t.Fatalf("ParseJSON() could not parse marshaled error %s, %v", first, err)
+		}
+
+		second, err := parsed.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON() error = %v", err)
+		}
+
+		if string(first) != string(second) {
+			t.Fatalf("json round trip was not stable got \n%s, want \n%s", second, first)
+		}
+	})
+}
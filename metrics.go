@@ -0,0 +1,137 @@
+package bear
+
+import (
+	"sync/atomic"
+
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+// MetricMode controls whether an error holds live metrics or snapshots of their values
+type MetricMode int
+
+const (
+	// MetricsLive keeps a reference to each metric, so the error always shows the metric's current value
+	MetricsLive MetricMode = iota
+	// MetricsSnapshot copies each metric's value when the error is created or wrapped,
+	// so the error shows the value at the time of the failure
+	MetricsSnapshot
+)
+
+// defaultMetricMode is the metric mode used by errors that don't set WithMetricMode
+var defaultMetricMode = int64(MetricsLive)
+
+// SetDefaultMetricMode sets the metric mode used by all errors that don't set WithMetricMode
+func SetDefaultMetricMode(mode MetricMode) {
+	atomic.StoreInt64(&defaultMetricMode, int64(mode))
+}
+
+// DefaultMetricMode returns the metric mode used by all errors that don't set WithMetricMode
+func DefaultMetricMode() MetricMode {
+	return MetricMode(atomic.LoadInt64(&defaultMetricMode))
+}
+
+// WithMetricMode sets whether the error holds live metrics or snapshots of their values
+func WithMetricMode(mode MetricMode) ErrOption {
+	return func(e *Error) {
+		e.metricMode = mode
+	}
+}
+
+// snapshotMetrics replaces any live metrics on the error with snapshots if the error is in snapshot mode
+func (e *Error) snapshotMetrics() {
+	if e.metricMode != MetricsSnapshot {
+		return
+	}
+
+	for i, m := range e.metrics {
+		if !m.IsSnapshot() {
+			e.metrics[i] = m.Snapshot()
+		}
+	}
+}
+
+// MetricsOption configures how metrics are merged by Error.Metrics
+type MetricsOption func(*metricsSettings)
+
+// metricsSettings are the settings used to merge metrics
+type metricsSettings struct {
+	merge metrics.MergeFunc
+}
+
+// MergeSum merges metrics by adding their values together, this is the default
+func MergeSum() MetricsOption {
+	return func(s *metricsSettings) {
+		s.merge = metrics.SumMetrics
+	}
+}
+
+// MergeMax merges metrics by keeping the metric with the largest value
+func MergeMax() MetricsOption {
+	return func(s *metricsSettings) {
+		s.merge = metrics.MaxMetrics
+	}
+}
+
+// MergeLast merges metrics by keeping the most recent metric, parents are older than the errors that wrap them
+func MergeLast() MetricsOption {
+	return func(s *metricsSettings) {
+		s.merge = metrics.LastMetric
+	}
+}
+
+// Metrics walks the error and all its parents and merges metrics with the same name and labels.
+// The merged metrics are snapshots and are returned in the order they were first found, starting from the oldest parent
+func (e *Error) Metrics(opts ...MetricsOption) []*metrics.Metric {
+	settings := metricsSettings{merge: metrics.SumMetrics}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	var keys []string
+	groups := make(map[string][]*metrics.Metric)
+	collectMetrics(e, func(m *metrics.Metric) {
+		key := m.GetName() + m.GetLabels().String()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], m)
+	})
+
+	merged := make([]*metrics.Metric, len(keys))
+	for i, key := range keys {
+		merged[i] = settings.merge(groups[key]...)
+	}
+	return merged
+}
+
+// collectMetrics calls fn with every metric in the error tree, parents are visited before the errors that wrap them.
+// Errors and metrics that show up more than once in the tree are only visited once so shared metrics are not counted twice
+func collectMetrics(err error, fn func(*metrics.Metric)) {
+	walkMetrics(err, map[*Error]struct{}{}, map[*metrics.Metric]struct{}{}, fn)
+}
+
+// walkMetrics is the recursive part of collectMetrics, seenErrs and seenMetrics track what has already been visited
+func walkMetrics(err error, seenErrs map[*Error]struct{}, seenMetrics map[*metrics.Metric]struct{}, fn func(*metrics.Metric)) {
+	berr, isBerr := err.(*Error)
+	if isBerr {
+		if _, ok := seenErrs[berr]; ok {
+			return
+		}
+		seenErrs[berr] = struct{}{}
+	}
+
+	for _, parent := range Unwrap(err) {
+		walkMetrics(parent, seenErrs, seenMetrics, fn)
+	}
+
+	if !isBerr {
+		return
+	}
+	for _, m := range berr.metrics {
+		if _, ok := seenMetrics[m]; ok {
+			continue
+		}
+		seenMetrics[m] = struct{}{}
+		fn(m)
+	}
+}
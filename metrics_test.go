@@ -0,0 +1,217 @@
+package bear
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+func TestWithMetricMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      func(m *metrics.Metric) *Error
+		want     int
+		wantJSON string
+	}{
+		{
+			"live metric",
+			func(m *metrics.Metric) *Error { return New(WithMetrics(m)) },
+			5,
+			`"metrics":[{"name":"retries","value":5}]`,
+		},
+		{
+			"snapshot metric",
+			func(m *metrics.Metric) *Error { return New(WithMetrics(m), WithMetricMode(MetricsSnapshot)) },
+			2,
+			`"metrics":[{"name":"retries","value":2,"snapshot":true}]`,
+		},
+		{
+			"snapshot wrapped error",
+			func(m *metrics.Metric) *Error { return Wrap(io.EOF, WithMetricMode(MetricsSnapshot), WithMetrics(m)) },
+			2,
+			`"metrics":[{"name":"retries","value":2,"snapshot":true}]`,
+		},
+		{
+			"snapshot template",
+			func(m *metrics.Metric) *Error {
+				tmpl := NewTemplate(WithMetrics(m))
+				return tmpl.New(WithMetricMode(MetricsSnapshot))
+			},
+			2,
+			`"metrics":[{"name":"retries","value":2,"snapshot":true}]`,
+		},
+		{
+			"snapshot added metric",
+			func(m *metrics.Metric) *Error {
+				return New(WithMetricMode(MetricsSnapshot)).Add(WithMetrics(m))
+			},
+			2,
+			`"metrics":[{"name":"retries","value":2,"snapshot":true}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.NewMetric("retries")
+			m.Add(2)
+			e := tt.err(m)
+			m.Add(3)
+
+			if got := e.metrics[0].GetValue(); got != tt.want {
+				t.Errorf("WithMetricMode() metric value = %d, want %d", got, tt.want)
+			}
+
+			raw, err := json.Marshal(e)
+			if err != nil {
+				t.Fatalf("Error.MarshalJSON() error = %v", err)
+			}
+			if !strings.Contains(string(raw), tt.wantJSON) {
+				t.Errorf("Error.MarshalJSON() = %s, want it to contain %s", raw, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestSetDefaultMetricMode(t *testing.T) {
+	defer SetDefaultMetricMode(DefaultMetricMode())
+
+	m := metrics.NewMetric("retries")
+	SetDefaultMetricMode(MetricsSnapshot)
+	snapshot := New(WithMetrics(m))
+	live := New(WithMetrics(m), WithMetricMode(MetricsLive))
+	m.Incr()
+
+	if got := snapshot.metrics[0].GetValue(); got != 0 {
+		t.Errorf("SetDefaultMetricMode() snapshot metric value = %d, want 0", got)
+	}
+	if got := live.metrics[0].GetValue(); got != 1 {
+		t.Errorf("SetDefaultMetricMode() WithMetricMode did not override the default, got %d", got)
+	}
+}
+
+func TestError_Metrics(t *testing.T) {
+	retries := func(n int) *metrics.Metric {
+		m := metrics.NewMetric("retries")
+		m.Add(n)
+		return m
+	}
+	users := metrics.NewMetric("retries").WithLabels(metrics.Labels{"table": "users"})
+	users.Add(7)
+
+	e := New(
+		WithMetrics(retries(1)),
+		WithParent(New(WithMetrics(retries(4), users))),
+		WithParent(fmt.Errorf("wrapped: %w", New(WithMetrics(retries(2))))),
+		WithParent(io.EOF),
+	)
+
+	type args struct {
+		opts []MetricsOption
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			"default sum",
+			args{
+				opts: nil,
+			},
+			[]string{"[retries] 7", `[retries{table="users"}] 7`},
+		},
+		{
+			"max",
+			args{
+				opts: []MetricsOption{MergeMax()},
+			},
+			[]string{"[retries] 4", `[retries{table="users"}] 7`},
+		},
+		{
+			"last",
+			args{
+				opts: []MetricsOption{MergeLast()},
+			},
+			[]string{"[retries] 1", `[retries{table="users"}] 7`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range e.Metrics(tt.args.opts...) {
+				got = append(got, m.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Error.Metrics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_MetricsDuplicates(t *testing.T) {
+	retries := metrics.NewMetric("retries")
+	retries.Add(3)
+	parent := New(WithMetrics(retries))
+
+	tests := []struct {
+		name string
+		err  *Error
+		want []string
+	}{
+		{
+			"shared metric",
+			Wrap(New(WithMetrics(retries)), WithMetrics(retries)),
+			[]string{"[retries] 3"},
+		},
+		{
+			"repeated parent",
+			New(WithParent(parent), WithParent(parent)),
+			[]string{"[retries] 3"},
+		},
+		{
+			"diamond",
+			New(WithParent(Wrap(parent)), WithParent(Wrap(parent))),
+			[]string{"[retries] 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range tt.err.Metrics() {
+				got = append(got, m.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Error.Metrics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFmtMergedMetrics(t *testing.T) {
+	inner := metrics.NewMetric("retries")
+	inner.Add(2)
+	outer := metrics.NewMetric("retries")
+	outer.Add(2)
+	e := Wrap(New(WithMetrics(inner)), WithMetrics(outer), FmtMergedMetrics(true), FmtNoStack(true))
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Error.MarshalJSON() error = %v", err)
+	}
+	want := `"mergedMetrics":[{"name":"retries","value":4,"snapshot":true}]`
+	if !strings.Contains(string(raw), want) {
+		t.Errorf("Error.MarshalJSON() = %s, want it to contain %s", raw, want)
+	}
+
+	parsed, err := ParseJSON(raw)
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	if len(parsed.metrics) != 1 {
+		t.Errorf("ParseJSON() parsed %d metrics, want the merged metrics to be ignored", len(parsed.metrics))
+	}
+}
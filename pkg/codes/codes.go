@@ -0,0 +1,170 @@
+package bearcodes
+
+import (
+	"strconv"
+
+	"github.com/bjatkin/bear"
+	beargrpc "github.com/bjatkin/bear/pkg/grpc"
+	bearhttp "github.com/bjatkin/bear/pkg/http"
+)
+
+// Code is a canonical error code that can be used for both http and grpc
+// the codes match the grpc status codes
+type Code int
+
+// canonical error codes
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+// codeInfo is the information for a canonical error code
+type codeInfo struct {
+	name        string
+	description string
+	retryable   bool
+	http        int
+	grpc        beargrpc.Code
+}
+
+// codes is the canonical mapping between the error codes, http statuses and grpc codes
+// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+var codes = map[Code]codeInfo{
+	OK:                 {"OK", "not an error, returned on success", false, 200, beargrpc.OK},
+	Canceled:           {"Canceled", "the operation was cancelled, typically by the caller", false, 499, beargrpc.Canceled},
+	Unknown:            {"Unknown", "unknown error", false, bearhttp.InternalServerError, beargrpc.Unknown},
+	InvalidArgument:    {"InvalidArgument", "the client specified an invalid argument", false, bearhttp.BadRequest, beargrpc.InvalidArgument},
+	DeadlineExceeded:   {"DeadlineExceeded", "the deadline expired before the operation could complete", true, bearhttp.GatewatyTimeout, beargrpc.DeadlineExceeded},
+	NotFound:           {"NotFound", "some requested entity was not found", false, bearhttp.NotFound, beargrpc.NotFound},
+	AlreadyExists:      {"AlreadyExists", "the entity that a client attempted to create already exists", false, bearhttp.Conflict, beargrpc.AlreadyExists},
+	PermissionDenied:   {"PermissionDenied", "the caller does not have permission to execute the operation", false, bearhttp.Forbidden, beargrpc.PermissionDenied},
+	ResourceExhausted:  {"ResourceExhausted", "some resource has been exhausted, e.g. a rate limit", true, bearhttp.TooManyRequests, beargrpc.ResourceExhausted},
+	FailedPrecondition: {"FailedPrecondition", "the system is not in a state required for the operation", false, bearhttp.BadRequest, beargrpc.FailedPrecondition},
+	Aborted:            {"Aborted", "the operation was aborted, typically due to a concurrency issue", true, bearhttp.Conflict, beargrpc.Aborted},
+	OutOfRange:         {"OutOfRange", "the operation was attempted past the valid range", false, bearhttp.BadRequest, beargrpc.OutOfRange},
+	Unimplemented:      {"Unimplemented", "the operation is not implemented or supported", false, bearhttp.NotImplemented, beargrpc.Unimplemented},
+	Internal:           {"Internal", "internal error, some invariant has been broken", false, bearhttp.InternalServerError, beargrpc.Internal},
+	Unavailable:        {"Unavailable", "the service is currently unavailable", true, bearhttp.ServiceUnavailable, beargrpc.Unavailable},
+	DataLoss:           {"DataLoss", "unrecoverable data loss or corruption", false, bearhttp.InternalServerError, beargrpc.DataLoss},
+	Unauthenticated:    {"Unauthenticated", "the request does not have valid authentication credentials", false, bearhttp.Unauthorized, beargrpc.Unauthenticated},
+}
+
+// httpCodes maps http statuses back to the canonical codes, statuses shared by
+// multiple codes map to the most general code
+var httpCodes = map[int]Code{
+	200:                          OK,
+	499:                          Canceled,
+	bearhttp.BadRequest:          InvalidArgument,
+	bearhttp.Unauthorized:        Unauthenticated,
+	bearhttp.Forbidden:           PermissionDenied,
+	bearhttp.NotFound:            NotFound,
+	bearhttp.RequestTimeout:      DeadlineExceeded,
+	bearhttp.Conflict:            AlreadyExists,
+	bearhttp.ReconditionFailed:   FailedPrecondition,
+	bearhttp.RangeNotSatisfiable: OutOfRange,
+	bearhttp.TooManyRequests:     ResourceExhausted,
+	bearhttp.InternalServerError: Internal,
+	bearhttp.NotImplemented:      Unimplemented,
+	bearhttp.BadGateway:          Unavailable,
+	bearhttp.ServiceUnavailable:  Unavailable,
+	bearhttp.GatewatyTimeout:     DeadlineExceeded,
+}
+
+// String implements the stringer interface
+func (c Code) String() string {
+	if info, ok := codes[c]; ok {
+		return info.name
+	}
+
+	return "Code(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Description returns a short description of the code
+func (c Code) Description() string {
+	return codes[c].description
+}
+
+// Retryable returns true if an operation that failed with the code can be retried
+func (c Code) Retryable() bool {
+	return codes[c].retryable
+}
+
+// HTTP returns the http status for the code, unknown codes return 500
+func (c Code) HTTP() int {
+	if info, ok := codes[c]; ok {
+		return info.http
+	}
+
+	return bearhttp.InternalServerError
+}
+
+// GRPC returns the grpc code for the code, unknown codes return Unknown
+func (c Code) GRPC() beargrpc.Code {
+	if info, ok := codes[c]; ok {
+		return info.grpc
+	}
+
+	return beargrpc.Unknown
+}
+
+// FromHTTP returns the canonical code for the http status. Unmapped 2xx statuses return OK,
+// unmapped 4xx statuses return FailedPrecondition and all other statuses return Unknown
+func FromHTTP(status int) Code {
+	if c, ok := httpCodes[status]; ok {
+		return c
+	}
+
+	switch {
+	case status >= 200 && status < 300:
+		return OK
+	case status >= 400 && status < 500:
+		return FailedPrecondition
+	default:
+		return Unknown
+	}
+}
+
+// FromGRPC returns the canonical code for the grpc code, invalid grpc codes return Unknown
+func FromGRPC(code beargrpc.Code) Code {
+	if !code.Valid() {
+		return Unknown
+	}
+
+	return Code(code)
+}
+
+// HTTPToGRPC converts an http status into a grpc code
+func HTTPToGRPC(status int) beargrpc.Code {
+	return FromHTTP(status).GRPC()
+}
+
+// GRPCToHTTP converts a grpc code into an http status
+func GRPCToHTTP(code beargrpc.Code) int {
+	return FromGRPC(code).HTTP()
+}
+
+// WithCode sets both the http and grpc codes on the error. The error's code is set to the
+// http status and the grpc code is stored in the beargrpc.CodeTag tag
+func WithCode(c Code) bear.ErrOption {
+	return func(e *bear.Error) {
+		e.Add(
+			bear.WithCode(c.HTTP()),
+			bear.WithTag(beargrpc.CodeTag, c.GRPC()),
+		)
+	}
+}
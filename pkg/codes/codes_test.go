@@ -0,0 +1,126 @@
+package bearcodes
+
+import (
+	"testing"
+
+	"github.com/bjatkin/bear"
+	beargrpc "github.com/bjatkin/bear/pkg/grpc"
+	bearhttp "github.com/bjatkin/bear/pkg/http"
+)
+
+func TestCode_GRPC(t *testing.T) {
+	for c := range codes {
+		if got := FromGRPC(c.GRPC()); got != c {
+			t.Errorf("FromGRPC(%s.GRPC()) = %s, want %s", c, got, c)
+		}
+		if c.String() != c.GRPC().String() {
+			t.Errorf("Code.String() = %s, want grpc name %s", c, c.GRPC())
+		}
+	}
+}
+
+func TestCode_HTTP(t *testing.T) {
+	for c := range codes {
+		// several codes share an http status but the status should always map back to a
+		// code with the same http status
+		if got := FromHTTP(c.HTTP()); got.HTTP() != c.HTTP() {
+			t.Errorf("FromHTTP(%s.HTTP()) = %s, want a code with status %d", c, got, c.HTTP())
+		}
+	}
+}
+
+func TestCode_Info(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          Code
+		wantString    string
+		wantRetryable bool
+	}{
+		{"unavailable", Unavailable, "Unavailable", true},
+		{"not found", NotFound, "NotFound", false},
+		{"unknown code", Code(99), "Code(99)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.code.String(); got != tt.wantString {
+				t.Errorf("Code.String() = %v, want %v", got, tt.wantString)
+			}
+			if got := tt.code.Retryable(); got != tt.wantRetryable {
+				t.Errorf("Code.Retryable() = %v, want %v", got, tt.wantRetryable)
+			}
+		})
+	}
+
+	if Code(99).Description() != "" || NotFound.Description() == "" {
+		t.Errorf("Code.Description() returned the wrong description")
+	}
+}
+
+func TestHTTPToGRPC(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   beargrpc.Code
+	}{
+		{"ok", 200, beargrpc.OK},
+		{"created", 201, beargrpc.OK},
+		{"bad request", bearhttp.BadRequest, beargrpc.InvalidArgument},
+		{"unauthorized", bearhttp.Unauthorized, beargrpc.Unauthenticated},
+		{"not found", bearhttp.NotFound, beargrpc.NotFound},
+		{"conflict", bearhttp.Conflict, beargrpc.AlreadyExists},
+		{"too many requests", bearhttp.TooManyRequests, beargrpc.ResourceExhausted},
+		{"teapot", bearhttp.ImATeapot, beargrpc.FailedPrecondition},
+		{"bad gateway", bearhttp.BadGateway, beargrpc.Unavailable},
+		{"gateway timeout", bearhttp.GatewatyTimeout, beargrpc.DeadlineExceeded},
+		{"loop detected", bearhttp.LoopDetected, beargrpc.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPToGRPC(tt.status); got != tt.want {
+				t.Errorf("HTTPToGRPC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCToHTTP(t *testing.T) {
+	tests := []struct {
+		name string
+		code beargrpc.Code
+		want int
+	}{
+		{"ok", beargrpc.OK, 200},
+		{"invalid argument", beargrpc.InvalidArgument, bearhttp.BadRequest},
+		{"not found", beargrpc.NotFound, bearhttp.NotFound},
+		{"unavailable", beargrpc.Unavailable, bearhttp.ServiceUnavailable},
+		{"unauthenticated", beargrpc.Unauthenticated, bearhttp.Unauthorized},
+		{"invalid code", beargrpc.Code(42), bearhttp.InternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GRPCToHTTP(tt.code); got != tt.want {
+				t.Errorf("GRPCToHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	err := bear.New(WithCode(AlreadyExists))
+
+	if got := bearhttp.StatusCode(err); got != bearhttp.Conflict {
+		t.Errorf("WithCode() http status = %d, want %d", got, bearhttp.Conflict)
+	}
+	if got := beargrpc.StatusCode(err); got != beargrpc.AlreadyExists {
+		t.Errorf("WithCode() grpc code = %s, want %s", got, beargrpc.AlreadyExists)
+	}
+
+	// the grpc code should also survive a json round trip
+	parsed, parseErr := bear.ParseJSON([]byte(err.Error()))
+	if parseErr != nil {
+		t.Fatalf("ParseJSON() error = %v", parseErr)
+	}
+	if got := beargrpc.StatusCode(parsed); got != beargrpc.AlreadyExists {
+		t.Errorf("WithCode() parsed grpc code = %s, want %s", got, beargrpc.AlreadyExists)
+	}
+}
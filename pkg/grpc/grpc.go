@@ -1,23 +1,66 @@
 package beargrpc
 
+import "strconv"
+
+// Code is a grpc status code
+type Code uint32
+
 // grpc return codes
 // https://pkg.go.dev/google.golang.org/grpc/codes
 const (
-	OK                 = 0
-	Canceled           = 1
-	Unknown            = 2
-	InvalidArggument   = 3
-	DeadlineExceeded   = 4
-	NotFound           = 5
-	AlreadyExists      = 6
-	PermissionDenied   = 7
-	ResourceExhausted  = 8
-	FailedPrecondition = 9
-	Aborted            = 10
-	OutOfRange         = 11
-	Unimplemented      = 12
-	Internal           = 13
-	Unavailable        = 14
-	DataLoss           = 15
-	Unauthenticated    = 16
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+
+	// InvalidArggument is kept for backwards compatibility, use InvalidArgument instead
+	InvalidArggument = InvalidArgument
 )
+
+// codeNames are the names of the codes, these match the names used by the grpc codes package
+var codeNames = map[Code]string{
+	OK:                 "OK",
+	Canceled:           "Canceled",
+	Unknown:            "Unknown",
+	InvalidArgument:    "InvalidArgument",
+	DeadlineExceeded:   "DeadlineExceeded",
+	NotFound:           "NotFound",
+	AlreadyExists:      "AlreadyExists",
+	PermissionDenied:   "PermissionDenied",
+	ResourceExhausted:  "ResourceExhausted",
+	FailedPrecondition: "FailedPrecondition",
+	Aborted:            "Aborted",
+	OutOfRange:         "OutOfRange",
+	Unimplemented:      "Unimplemented",
+	Internal:           "Internal",
+	Unavailable:        "Unavailable",
+	DataLoss:           "DataLoss",
+	Unauthenticated:    "Unauthenticated",
+}
+
+// String implements the stringer interface
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// Valid returns true if the code is one of the grpc status codes
+func (c Code) Valid() bool {
+	return c <= Unauthenticated
+}
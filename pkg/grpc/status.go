@@ -0,0 +1,189 @@
+package beargrpc
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/bjatkin/bear"
+)
+
+// DetailsTag is the bear error tag used to store the details of a grpc status
+const DetailsTag = "grpcDetails"
+
+// CodeTag is the bear error tag used to store a grpc code when the error's code is used for something else
+// (e.g. an http status). It takes priority over the error's code
+const CodeTag = "grpcCode"
+
+// Status is the part of a grpc status used by beargrpc. It mirrors *status.Status from
+// google.golang.org/grpc/status so a small adapter is all that's needed to use real grpc statuses
+type Status interface {
+	Code() Code
+	Message() string
+	Details() []interface{}
+}
+
+// StatusError is an error that carries a grpc status
+type StatusError interface {
+	error
+	GRPCStatus() Status
+}
+
+// statusError is a grpc status that is also an error
+type statusError struct {
+	code    Code
+	message string
+	details []interface{}
+}
+
+// NewStatus creates a new grpc status, the returned status also implements StatusError
+func NewStatus(code Code, message string, details ...interface{}) Status {
+	return &statusError{
+		code:    code,
+		message: message,
+		details: details,
+	}
+}
+
+// Code returns the status code
+func (s *statusError) Code() Code {
+	return s.code
+}
+
+// Message returns the status message
+func (s *statusError) Message() string {
+	return s.message
+}
+
+// Details returns the status details
+func (s *statusError) Details() []interface{} {
+	return s.details
+}
+
+// Error implements the error interface
+func (s *statusError) Error() string {
+	return "rpc error: code = " + s.code.String() + " desc = " + s.message
+}
+
+// GRPCStatus implements the StatusError interface
+func (s *statusError) GRPCStatus() Status {
+	return s
+}
+
+// WithCode adds the grpc code to the error as the error's code
+func WithCode(code Code) bear.ErrOption {
+	return bear.WithCode(int(code))
+}
+
+// StatusCode returns the first grpc error code (1-16) found in the error tree.
+// Errors carrying a grpc status use the status code. If no code is found Unknown is returned
+func StatusCode(err error) Code {
+	if code, ok := findCode(err); ok {
+		return code
+	}
+
+	return Unknown
+}
+
+// findCode returns the first grpc error code (1-16) found in the error tree
+func findCode(err error) (Code, bool) {
+	switch v := err.(type) {
+	case StatusError:
+		if code := v.GRPCStatus().Code(); code != OK {
+			return code, true
+		}
+	case *bear.Error:
+		if code, ok := tagCode(v); ok && code != OK && code.Valid() {
+			return code, true
+		}
+		if code, ok := v.GetCode(); ok && code > int(OK) && Code(code).Valid() {
+			return Code(code), true
+		}
+	}
+
+	for _, parent := range bear.Unwrap(err) {
+		if code, ok := findCode(parent); ok {
+			return code, true
+		}
+	}
+
+	return 0, false
+}
+
+// tagCode returns the grpc code stored in the errors CodeTag
+func tagCode(e *bear.Error) (Code, bool) {
+	tag, ok := e.GetTag(CodeTag)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := tag.(type) {
+	case Code:
+		return v, true
+	case int:
+		return Code(v), v >= 0
+	case float64:
+		return Code(v), v >= 0
+	case json.Number:
+		code, err := strconv.ParseUint(v.String(), 10, 32)
+		return Code(code), err == nil
+	}
+
+	return 0, false
+}
+
+// ToStatus converts an error into a grpc status. The code is found using StatusCode, the message is
+// the error's message (or error type for bear errors without a message), and the details are
+// taken from the DetailsTag. Errors that already carry a grpc status return that status
+func ToStatus(err error) Status {
+	if err == nil {
+		return NewStatus(OK, "")
+	}
+
+	if serr, ok := err.(StatusError); ok {
+		return serr.GRPCStatus()
+	}
+
+	berr, ok := err.(*bear.Error)
+	if !ok {
+		return NewStatus(StatusCode(err), err.Error())
+	}
+
+	code := StatusCode(berr)
+	message, ok := berr.GetMsg()
+	if !ok {
+		if errType, ok := berr.GetErrType(); ok {
+			message = string(errType)
+		} else {
+			message = code.String()
+		}
+	}
+
+	var details []interface{}
+	if tag, ok := berr.GetTag(DetailsTag); ok {
+		details, _ = tag.([]interface{})
+	}
+
+	return NewStatus(code, message, details...)
+}
+
+// FromStatus converts a grpc status into a bear error, the code, message and details are preserved
+// so the status can be recreated with ToStatus
+func FromStatus(s Status, opts ...bear.ErrOption) *bear.Error {
+	opts = append([]bear.ErrOption{WithCode(s.Code()), bear.WithMsg(s.Message())}, opts...)
+	if details := s.Details(); len(details) > 0 {
+		opts = append(opts, bear.WithTag(DetailsTag, details))
+	}
+
+	return bear.New(opts...)
+}
+
+// FromError wraps the error in a new bear error, if the error carries a grpc status
+// its code, message and details are added to the new error
+func FromError(err error, opts ...bear.ErrOption) *bear.Error {
+	serr, ok := err.(StatusError)
+	if !ok {
+		return bear.Wrap(err, opts...)
+	}
+
+	return FromStatus(serr.GRPCStatus(), append(opts, bear.WithParent(err))...)
+}
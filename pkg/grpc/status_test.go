@@ -0,0 +1,168 @@
+package beargrpc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+// fakeStatus mimics a grpc status error from another package
+type fakeStatus struct {
+	code    Code
+	message string
+	details []interface{}
+}
+
+func (s fakeStatus) Code() Code             { return s.code }
+func (s fakeStatus) Message() string        { return s.message }
+func (s fakeStatus) Details() []interface{} { return s.details }
+func (s fakeStatus) Error() string          { return s.message }
+func (s fakeStatus) GRPCStatus() Status     { return s }
+
+func TestCode_String(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want string
+	}{
+		{"ok", OK, "OK"},
+		{"not found", NotFound, "NotFound"},
+		{"invalid argument", InvalidArggument, "InvalidArgument"},
+		{"unknown code", Code(42), "Code(42)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.code.String(); got != tt.want {
+				t.Errorf("Code.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    Code
+		wantMessage string
+		wantDetails []interface{}
+	}{
+		{
+			"nil error",
+			nil,
+			OK,
+			"",
+			nil,
+		},
+		{
+			"bear error",
+			bear.New(WithCode(NotFound), bear.WithMsg("user not found")),
+			NotFound,
+			"user not found",
+			nil,
+		},
+		{
+			"bear error type",
+			bear.New(WithCode(PermissionDenied), bear.WithErrType(bear.NewType("Forbidden"))),
+			PermissionDenied,
+			"Forbidden",
+			nil,
+		},
+		{
+			"wrapped code",
+			bear.Wrap(bear.New(WithCode(Unavailable)), bear.WithMsg("db down")),
+			Unavailable,
+			"db down",
+			nil,
+		},
+		{
+			"non grpc code",
+			bear.New(bear.WithCode(404)),
+			Unknown,
+			"Unknown",
+			nil,
+		},
+		{
+			"with details",
+			bear.New(WithCode(InvalidArgument), bear.WithMsg("bad"), bear.WithTag(DetailsTag, []interface{}{"field"})),
+			InvalidArgument,
+			"bad",
+			[]interface{}{"field"},
+		},
+		{
+			"std error",
+			errors.New("std error"),
+			Unknown,
+			"std error",
+			nil,
+		},
+		{
+			"status error",
+			fakeStatus{code: Aborted, message: "aborted", details: []interface{}{1}},
+			Aborted,
+			"aborted",
+			[]interface{}{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToStatus(tt.err)
+			if got.Code() != tt.wantCode {
+				t.Errorf("ToStatus() code = %v, want %v", got.Code(), tt.wantCode)
+			}
+			if got.Message() != tt.wantMessage {
+				t.Errorf("ToStatus() message = %v, want %v", got.Message(), tt.wantMessage)
+			}
+			if !reflect.DeepEqual(got.Details(), tt.wantDetails) {
+				t.Errorf("ToStatus() details = %v, want %v", got.Details(), tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	remote := &fakeStatus{code: NotFound, message: "missing", details: []interface{}{"detail"}}
+	err := FromError(remote, bear.WithLabels("remote"))
+
+	if !errors.Is(err, remote) {
+		t.Errorf("FromError() did not wrap the status error")
+	}
+	if !err.HasLabel("remote") {
+		t.Errorf("FromError() did not apply the options")
+	}
+	if code, _ := err.GetCode(); Code(code) != NotFound {
+		t.Errorf("FromError() code = %v, want %v", Code(code), NotFound)
+	}
+
+	// the status should survive the round trip back to a status
+	got := ToStatus(err)
+	if got.Code() != remote.code || got.Message() != remote.message || !reflect.DeepEqual(got.Details(), remote.details) {
+		t.Errorf("ToStatus(FromError()) = %v %v %v, want %v %v %v",
+			got.Code(), got.Message(), got.Details(), remote.code, remote.message, remote.details)
+	}
+
+	std := errors.New("std error")
+	if err := FromError(std); !errors.Is(err, std) || StatusCode(err) != Unknown {
+		t.Errorf("FromError() did not wrap std error %v", err)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	s := NewStatus(ResourceExhausted, "slow down")
+	err := FromStatus(s)
+
+	if code, _ := err.GetCode(); Code(code) != ResourceExhausted {
+		t.Errorf("FromStatus() code = %v, want %v", Code(code), ResourceExhausted)
+	}
+	if msg, _ := err.GetMsg(); msg != "slow down" {
+		t.Errorf("FromStatus() msg = %v, want slow down", msg)
+	}
+	if err.HasTag(DetailsTag) {
+		t.Errorf("FromStatus() set details tag for status without details")
+	}
+	if got := s.(StatusError).Error(); got != "rpc error: code = ResourceExhausted desc = slow down" {
+		t.Errorf("statusError.Error() = %v", got)
+	}
+}
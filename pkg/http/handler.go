@@ -0,0 +1,34 @@
+package bearhttp
+
+import (
+	"net/http"
+)
+
+// HandlerFunc is an http handler that returns an error rather than writing the error response itself
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// ServeHTTP implements the http.Handler interface using the default options
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serve(f, w, r, newOptions(nil))
+}
+
+// Handle adapts a HandlerFunc into an http.Handler. Any error returned by the handler is
+// reported and written as problem+json. The status is taken from the error's code, then the
+// WithTypeStatus options, and defaults to 500. Stack traces are only included if Debug is set.
+// The trace from the traceparent header is added to the request's context
+func Handle(h HandlerFunc, opts ...Option) http.Handler {
+	o := newOptions(opts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(h, w, r, o)
+	})
+}
+
+// serve calls the handler and handles any errors it returns
+func serve(h HandlerFunc, w http.ResponseWriter, r *http.Request, o *options) {
+	rw, w := wrapResponseWriter(w)
+	r = withTrace(r)
+	if err := h(w, r); err != nil {
+		handleError(rw, r, err, o)
+	}
+}
@@ -0,0 +1,162 @@
+package bearhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+func TestHandle(t *testing.T) {
+	notFound := bear.NewType("Not Found")
+	userNotFound := bear.NewType("User Not Found", notFound)
+
+	tests := []struct {
+		name       string
+		err        error
+		opts       []Option
+		wantStatus int
+		want       map[string]interface{}
+	}{
+		{
+			"no error",
+			nil,
+			nil,
+			http.StatusOK,
+			nil,
+		},
+		{
+			"error code",
+			bear.New(bear.WithCode(Conflict), bear.WithMsg("user already exists"), bear.WithLabels("users", "db")),
+			nil,
+			Conflict,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Conflict",
+				"status": float64(409),
+				"detail": "user already exists",
+				"labels": []interface{}{"db", "users"},
+			},
+		},
+		{
+			"error type status",
+			bear.New(bear.WithErrType(userNotFound)),
+			[]Option{WithTypeStatus(notFound, NotFound)},
+			NotFound,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "User Not Found",
+				"status": float64(404),
+			},
+		},
+		{
+			"code beats error type",
+			bear.New(bear.WithErrType(userNotFound), bear.WithCode(Gone)),
+			[]Option{WithTypeStatus(notFound, NotFound)},
+			Gone,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "User Not Found",
+				"status": float64(410),
+			},
+		},
+		{
+			"wrapped code",
+			bear.Wrap(bear.New(bear.WithCode(Unauthorized)), bear.WithMsg("login failed")),
+			nil,
+			Unauthorized,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Unauthorized",
+				"status": float64(401),
+				"detail": "login failed",
+			},
+		},
+		{
+			"std error",
+			errors.New("pq: connection refused"),
+			nil,
+			InternalServerError,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Internal Server Error",
+				"status": float64(500),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Handle(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.err == nil {
+					w.WriteHeader(http.StatusOK)
+				}
+				return tt.err
+			}, tt.opts...)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Handle() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.want == nil {
+				if w.Body.Len() != 0 {
+					t.Errorf("Handle() wrote unexpected body %s", w.Body.String())
+				}
+				return
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Handle() wrote invalid json %s, %v", w.Body.String(), err)
+			}
+			delete(got, "instance")
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Handle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandle_Debug(t *testing.T) {
+	err := bear.Wrap(bear.New(bear.WithMsg("inner")), bear.WithCode(BadRequest))
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		return err
+	}
+
+	tests := []struct {
+		name      string
+		handler   http.Handler
+		wantStack bool
+	}{
+		{"handler func", HandlerFunc(h), false},
+		{"no debug", Handle(h), false},
+		{"debug", Handle(h, Debug(true)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var got struct {
+				Stack   []string          `json:"stack"`
+				Parents []json.RawMessage `json:"parents"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Handle() wrote invalid json %s, %v", w.Body.String(), err)
+			}
+
+			if (len(got.Stack) > 0) != tt.wantStack {
+				t.Errorf("Handle() stack = %v, wantStack %v", got.Stack, tt.wantStack)
+			}
+			if (len(got.Parents) > 0) != tt.wantStack {
+				t.Errorf("Handle() parents = %s, wantStack %v", got.Parents, tt.wantStack)
+			}
+		})
+	}
+}
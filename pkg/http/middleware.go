@@ -0,0 +1,219 @@
+package bearhttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/bjatkin/bear"
+)
+
+// DefaultRequestIDHeader is the header used to tag errors with a request id
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// Reporter is called with every error that is written as a response
+type Reporter func(r *http.Request, err *bear.Error)
+
+// WithReporter sets the reporter that is called with every error the middleware handles
+func WithReporter(reporter Reporter) Option {
+	return func(o *options) {
+		o.reporter = reporter
+	}
+}
+
+// WithRequestIDHeader sets the header used to tag errors with a request id, the default is X-Request-Id
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		o.requestIDHeader = header
+	}
+}
+
+// Middleware recovers any panics in the next handler and converts them into bear errors.
+// The errors are tagged with the request method, path, remote address and request id,
+// and are written as problem+json responses using the status from StatusCode.
+// The trace from the traceparent header is added to the errors and the request's context
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	o := newOptions(append([]Option{WithRequestIDHeader(DefaultRequestIDHeader)}, opts...))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw, w := wrapResponseWriter(w)
+		r = withTrace(r)
+
+		// recovered only holds the panic error, the request error is created once a panic happens
+		// so requests that succeed are not counted by bear.CollectErrors
+		recovered := &bear.Error{}
+		aborted := false
+		func() {
+			defer recovered.WrapPanic()
+			// http.ErrAbortHandler is used to abort a response so it's recovered before WrapPanic
+			// creates a panic error for it, any other panic is passed on to WrapPanic
+			defer func() {
+				if v := recover(); v != nil {
+					if v == http.ErrAbortHandler {
+						aborted = true
+						return
+					}
+					panic(v)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		}()
+
+		if aborted || bear.IsAny(recovered, http.ErrAbortHandler) {
+			panic(http.ErrAbortHandler)
+		}
+		if len(recovered.Unwrap()) == 0 {
+			return
+		}
+
+		// the panic error has already captured the stack, so there's no need to capture it here
+		opts := append(requestTags(r, o), bear.WithStackDepth(bear.StackNone), bear.WithParent(recovered.Unwrap()[0]))
+		e := bear.NewCtx(r.Context(), opts...)
+
+		handleError(rw, r, e, o)
+	})
+}
+
+// handleError reports the error and writes it to the response if nothing has been written yet
+func handleError(rw *responseWriter, r *http.Request, err error, o *options) {
+	if o.reporter != nil {
+		berr, _ := bear.AsBerr(err)
+		o.reporter(r, berr)
+		err = berr
+	}
+
+	// the handler has already started the response so it's too late to write the error
+	if rw.wroteHeader {
+		return
+	}
+
+	writeError(rw, err, o)
+}
+
+// requestTags creates tags for the request
+func requestTags(r *http.Request, o *options) []bear.ErrOption {
+	tags := []bear.ErrOption{
+		bear.WithTag("method", r.Method),
+		bear.WithTag("path", r.URL.Path),
+		bear.WithTag("remoteAddr", r.RemoteAddr),
+	}
+
+	if id := r.Header.Get(o.requestIDHeader); id != "" {
+		tags = append(tags, bear.WithTag("requestID", id))
+	}
+
+	return tags
+}
+
+// responseWriter tracks if the response has been started
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush implements the http.Flusher interface, it's only exposed if the underlying writer is a http.Flusher
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface, it's only exposed if the underlying writer is a http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// ReadFrom implements the io.ReaderFrom interface, it's only exposed if the underlying writer is an io.ReaderFrom
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// unwrapper is implemented by response writers that wrap another http.ResponseWriter
+type unwrapper interface {
+	Unwrap() http.ResponseWriter
+}
+
+// wrapResponseWriter wraps w in a responseWriter, the returned http.ResponseWriter should be passed to handlers.
+// It only implements the http.Flusher, http.Hijacker and io.ReaderFrom interfaces if w does, so handlers can
+// still check for them (e.g. to stream responses) without seeing support the underlying writer does not have
+func wrapResponseWriter(w http.ResponseWriter) (*responseWriter, http.ResponseWriter) {
+	rw := &responseWriter{ResponseWriter: w}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isReaderFrom := w.(io.ReaderFrom)
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, rw, rw, rw, rw}
+	case isFlusher && isHijacker:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			http.Hijacker
+		}{rw, rw, rw, rw}
+	case isFlusher && isReaderFrom:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			io.ReaderFrom
+		}{rw, rw, rw, rw}
+	case isHijacker && isReaderFrom:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, rw, rw, rw}
+	case isFlusher:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+		}{rw, rw, rw}
+	case isHijacker:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			http.Hijacker
+		}{rw, rw, rw}
+	case isReaderFrom:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+			io.ReaderFrom
+		}{rw, rw, rw}
+	default:
+		return rw, struct {
+			http.ResponseWriter
+			unwrapper
+		}{rw, rw}
+	}
+}
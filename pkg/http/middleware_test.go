@@ -0,0 +1,235 @@
+package bearhttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/bear/pkg/metrics"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantReport bool
+	}{
+		{
+			"no panic",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			http.StatusOK,
+			"ok",
+			false,
+		},
+		{
+			"panic with string",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic("handler failed")
+			},
+			InternalServerError,
+			"",
+			true,
+		},
+		{
+			"panic with bear error",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic(bear.New(bear.WithCode(NotFound), bear.WithMsg("missing user")))
+			},
+			NotFound,
+			"",
+			true,
+		},
+		{
+			"panic with std error",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic(io.ErrUnexpectedEOF)
+			},
+			InternalServerError,
+			"",
+			true,
+		},
+		{
+			"panic after writing",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte("partial"))
+				panic("handler failed")
+			},
+			http.StatusAccepted,
+			"partial",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported *bear.Error
+			var reportedReq *http.Request
+			server := httptest.NewServer(Middleware(tt.handler, WithReporter(func(r *http.Request, err *bear.Error) {
+				reported = err
+				reportedReq = r
+			})))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/users/12", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() error = %v", err)
+			}
+			req.Header.Set(DefaultRequestIDHeader, "request-1")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Middleware() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("io.ReadAll() error = %v", err)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("Middleware() body = %s, want %s", body, tt.wantBody)
+			}
+
+			if (reported != nil) != tt.wantReport {
+				t.Fatalf("Middleware() reported = %v, wantReport %v", reported, tt.wantReport)
+			}
+			if !tt.wantReport {
+				return
+			}
+
+			if !bear.Is(reported, bear.PanicErr) {
+				t.Errorf("Middleware() reported error was not a panic error %v", reported)
+			}
+			if reportedReq == nil || reportedReq.URL.Path != "/users/12" {
+				t.Errorf("Middleware() reported the wrong request")
+			}
+
+			wantTags := map[string]interface{}{
+				"method":    http.MethodPost,
+				"path":      "/users/12",
+				"requestID": "request-1",
+			}
+			for tag, want := range wantTags {
+				if got, _ := reported.GetTag(tag); got != want {
+					t.Errorf("Middleware() tag %s = %v, want %v", tag, got, want)
+				}
+			}
+			if !reported.HasTag("remoteAddr") {
+				t.Errorf("Middleware() remote address tag was not set")
+			}
+
+			if tt.wantBody != "" {
+				return
+			}
+
+			if got := resp.Header.Get("Content-Type"); got != ProblemContentType {
+				t.Errorf("Middleware() content type = %s, want %s", got, ProblemContentType)
+			}
+
+			var problem struct {
+				Instance string `json:"instance"`
+			}
+			if err := json.Unmarshal(body, &problem); err != nil {
+				t.Fatalf("Middleware() wrote invalid json %s, %v", body, err)
+			}
+			if problem.Instance != reported.GetID() {
+				t.Errorf("Middleware() instance = %s, want %s", problem.Instance, reported.GetID())
+			}
+		})
+	}
+}
+
+func TestMiddleware_AbortHandler(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("Middleware() panic = %v, want http.ErrAbortHandler", v)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	t.Errorf("Middleware() did not re-panic with http.ErrAbortHandler")
+}
+
+func TestMiddleware_CollectErrors(t *testing.T) {
+	r := metrics.NewRegistry()
+	bear.CollectErrors(r)
+	defer bear.CollectErrors(nil)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	}
+
+	if samples := r.Snapshot(); len(samples) != 0 {
+		t.Errorf("Middleware() counted errors for successful requests, got %v", samples)
+	}
+
+	aborted := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() { _ = recover() }()
+		aborted.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	}()
+
+	if samples := r.Snapshot(); len(samples) != 0 {
+		t.Errorf("Middleware() counted errors for aborted requests, got %v", samples)
+	}
+
+	panicking := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("panicking")
+	}))
+	panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	var total int
+	for _, sample := range r.Snapshot() {
+		total += int(sample.Value)
+	}
+	// the panic error and the request error that wraps it
+	if total != 2 {
+		t.Errorf("Middleware() counted %d errors for a panicking request, want 2", total)
+	}
+}
+
+func TestMiddleware_Flush(t *testing.T) {
+	var flusher, hijacker bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker = w.(http.Hijacker)
+		f, ok := w.(http.Flusher)
+		if flusher = ok; ok {
+			f.Flush()
+		}
+		panic("panicking after the response was flushed")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !flusher || !w.Flushed {
+		t.Errorf("Middleware() handler could not flush the response")
+	}
+	if hijacker {
+		t.Errorf("Middleware() handler can hijack a writer that does not support it")
+	}
+	// the flush started the response so the error should not be written
+	if got := w.Header().Get("Content-Type"); got == ProblemContentType {
+		t.Errorf("Middleware() wrote an error after the response was flushed")
+	}
+}
@@ -0,0 +1,304 @@
+package bearhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/bjatkin/bear"
+)
+
+// ProblemContentType is the content type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response
+// https://datatracker.ietf.org/doc/html/rfc7807
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// problemMembers are the members defined by RFC 7807, extensions can not override them
+var problemMembers = map[string]struct{}{
+	"type":     {},
+	"title":    {},
+	"status":   {},
+	"detail":   {},
+	"instance": {},
+}
+
+// MarshalJSON implements the marshaler interface, extensions are added as top level members
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		if _, ok := problemMembers[name]; !ok {
+			members[name] = value
+		}
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+// Option configures how errors are converted into http responses
+type Option func(*options)
+
+// options are the settings used to convert errors into http responses
+type options struct {
+	typeURI         string
+	exposeStack     bool
+	exposeParents   bool
+	logger          func(*bear.Error)
+	reporter        Reporter
+	requestIDHeader string
+	typeStatuses    []typeStatus
+}
+
+// typeStatus is the http status used for an error type
+type typeStatus struct {
+	errType bear.ErrType
+	status  int
+}
+
+// newOptions creates the options from the list of Options
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithTypeURI sets the base uri used for the problem type, the error type is appended to the base uri.
+// If no base uri is set the problem type is about:blank
+func WithTypeURI(base string) Option {
+	return func(o *options) {
+		o.typeURI = base
+	}
+}
+
+// ExposeStack adds the error's stack trace to the response as the stack extension
+// this should only be used for debugging since it leaks internal details to clients
+func ExposeStack(on bool) Option {
+	return func(o *options) {
+		o.exposeStack = on
+	}
+}
+
+// ExposeParents adds the error's parents to the response as the parents extension
+// this should only be used for debugging since it leaks internal details to clients
+func ExposeParents(on bool) Option {
+	return func(o *options) {
+		o.exposeParents = on
+	}
+}
+
+// Debug exposes the error's stack trace and parents in the response
+// this should never be used in production since it leaks internal details to clients
+func Debug(on bool) Option {
+	return func(o *options) {
+		o.exposeStack = on
+		o.exposeParents = on
+	}
+}
+
+// WithTypeStatus sets the http status used for errors of the given type (or any of its child types)
+// when the error does not have an http status code. Types are checked in the order they're added
+func WithTypeStatus(t bear.ErrType, status int) Option {
+	return func(o *options) {
+		o.typeStatuses = append(o.typeStatuses, typeStatus{errType: t, status: status})
+	}
+}
+
+// WithLogger sets a function that is called with the full error before the response is written
+// this can be used to log the internal details of an error server side
+func WithLogger(logger func(*bear.Error)) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// NewProblem converts an error into problem details.
+// The status is set from the first http error code in the error tree, then from any WithTypeStatus options,
+// and otherwise defaults to 500.
+// Errors that are not bear errors do not set the detail since their messages may contain internal details.
+// A nil error returns an empty Problem
+func NewProblem(err error, opts ...Option) Problem {
+	if err == nil {
+		return Problem{}
+	}
+
+	berr, ok := bear.AsBerr(err)
+	return newProblem(berr, ok, newOptions(opts))
+}
+
+// newProblem converts a bear error into problem details, isBerr should be false
+// if the error was converted from a non bear error
+func newProblem(berr *bear.Error, isBerr bool, o *options) Problem {
+	p := Problem{
+		Type:     "about:blank",
+		Status:   o.statusCode(berr),
+		Instance: berr.GetID(),
+	}
+
+	if errType, ok := berr.GetErrType(); ok {
+		p.Title = string(errType)
+		if o.typeURI != "" {
+			p.Type = o.typeURI + url.PathEscape(string(errType))
+		}
+	} else {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	if msg, ok := berr.GetMsg(); ok && isBerr {
+		p.Detail = msg
+	}
+
+	p.Extensions = berr.GetTags()
+	if labels := berr.GetLabels(); len(labels) > 0 {
+		p.Extensions["labels"] = labels
+	}
+	if traceID, ok := findTrace(berr); ok {
+		p.Extensions["traceId"] = traceID
+	}
+	if o.exposeStack {
+		if stack := berr.GetStack(); len(stack) > 0 {
+			p.Extensions["stack"] = stack
+		}
+	}
+	if o.exposeParents {
+		if parents := berr.Unwrap(); len(parents) > 0 {
+			jsonParents := make([]json.RawMessage, 0, len(parents))
+			for _, parent := range parents {
+				parentErr, _ := bear.AsBerr(parent)
+				raw := json.RawMessage(parentErr.Error())
+				if !o.exposeStack {
+					raw = withoutStacks(raw)
+				}
+				jsonParents = append(jsonParents, raw)
+			}
+			p.Extensions["parents"] = jsonParents
+		}
+	}
+
+	return p
+}
+
+// StatusCode returns the first http error status (400-599) found in the error tree
+// if no http error status is found then InternalServerError is returned
+func StatusCode(err error) int {
+	if status, ok := findStatus(err); ok {
+		return status
+	}
+
+	return InternalServerError
+}
+
+// withoutStacks removes the stack traces from a bear json error and all its parents.
+// If the json can not be parsed null is returned so the stacks are never leaked
+func withoutStacks(raw json.RawMessage) json.RawMessage {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return json.RawMessage("null")
+	}
+	delete(members, "stack")
+
+	if rawParents, ok := members["parents"]; ok {
+		var parents []json.RawMessage
+		if err := json.Unmarshal(rawParents, &parents); err != nil {
+			return json.RawMessage("null")
+		}
+		for i, parent := range parents {
+			parents[i] = withoutStacks(parent)
+		}
+
+		stripped, err := json.Marshal(parents)
+		if err != nil {
+			return json.RawMessage("null")
+		}
+		members["parents"] = stripped
+	}
+
+	stripped, err := json.Marshal(members)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return stripped
+}
+
+// findStatus returns the first http error status (400-599) found in the error tree
+func findStatus(err error) (int, bool) {
+	if berr, ok := err.(*bear.Error); ok {
+		if code, ok := berr.GetCode(); ok && code >= BadRequest && code <= 599 {
+			return code, true
+		}
+	}
+
+	for _, parent := range bear.Unwrap(err) {
+		if status, ok := findStatus(parent); ok {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
+
+// statusCode returns the http status for the error, errors without an http status code
+// use the status of the first matching WithTypeStatus option
+func (o *options) statusCode(err error) int {
+	if status, ok := findStatus(err); ok {
+		return status
+	}
+
+	for _, ts := range o.typeStatuses {
+		if bear.Is(err, ts.errType) {
+			return ts.status
+		}
+	}
+
+	return InternalServerError
+}
+
+// WriteError writes the error to the response as RFC 7807 problem details
+// nothing is written if the error is nil
+func WriteError(w http.ResponseWriter, err error, opts ...Option) {
+	writeError(w, err, newOptions(opts))
+}
+
+// writeError writes the error to the response as RFC 7807 problem details
+func writeError(w http.ResponseWriter, err error, o *options) {
+	if err == nil {
+		return
+	}
+
+	berr, ok := bear.AsBerr(err)
+	if o.logger != nil {
+		o.logger(berr)
+	}
+
+	p := newProblem(berr, ok, o)
+	raw, jsonErr := json.Marshal(p)
+	if jsonErr != nil {
+		// tags may not be json encodable so fall back to the problem without any extensions
+		p.Extensions = nil
+		raw, _ = json.Marshal(p)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(raw)
+}
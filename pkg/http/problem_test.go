@@ -0,0 +1,232 @@
+package bearhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+func TestWriteError(t *testing.T) {
+	notFound := bear.NewType("Not Found")
+
+	tests := []struct {
+		name       string
+		err        error
+		opts       []Option
+		wantStatus int
+		want       map[string]interface{}
+	}{
+		{
+			"bear error",
+			bear.New(bear.WithCode(NotFound), bear.WithErrType(notFound), bear.WithMsg("user 12 was not found"), bear.WithTag("user", 12)),
+			nil,
+			NotFound,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Not Found",
+				"status": float64(404),
+				"detail": "user 12 was not found",
+				"user":   float64(12),
+			},
+		},
+		{
+			"type uri",
+			bear.New(bear.WithCode(NotFound), bear.WithErrType(notFound)),
+			[]Option{WithTypeURI("https://example.com/errors/")},
+			NotFound,
+			map[string]interface{}{
+				"type":   "https://example.com/errors/Not%20Found",
+				"title":  "Not Found",
+				"status": float64(404),
+			},
+		},
+		{
+			"no error type",
+			bear.New(bear.WithCode(Conflict)),
+			nil,
+			Conflict,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Conflict",
+				"status": float64(409),
+			},
+		},
+		{
+			"non http code",
+			bear.New(bear.WithCode(7)),
+			nil,
+			InternalServerError,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Internal Server Error",
+				"status": float64(500),
+			},
+		},
+		{
+			"std error",
+			errors.New("secret connection string"),
+			nil,
+			InternalServerError,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Internal Server Error",
+				"status": float64(500),
+			},
+		},
+		{
+			"reserved tag names",
+			bear.New(bear.WithCode(BadRequest), bear.WithTag("status", 200), bear.WithTag("field", "name")),
+			nil,
+			BadRequest,
+			map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Bad Request",
+				"status": float64(400),
+				"field":  "name",
+			},
+		},
+		{
+			"expose parents",
+			bear.Wrap(bear.New(bear.WithCode(1), bear.FmtNoStack(true), bear.FmtNoID(true)), bear.WithCode(BadGateway)),
+			[]Option{ExposeParents(true)},
+			BadGateway,
+			map[string]interface{}{
+				"type":    "about:blank",
+				"title":   "Bad Gateway",
+				"status":  float64(502),
+				"parents": []interface{}{map[string]interface{}{"code": float64(1)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, tt.err, tt.opts...)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("WriteError() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != ProblemContentType {
+				t.Errorf("WriteError() content type = %s, want %s", got, ProblemContentType)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("WriteError() wrote invalid json %s, %v", w.Body.String(), err)
+			}
+
+			// the instance is a random id so just check that it was set
+			if got["instance"] == "" {
+				t.Errorf("WriteError() instance was not set")
+			}
+			delete(got, "instance")
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteError_Stack(t *testing.T) {
+	err := bear.New(bear.WithCode(BadRequest))
+
+	if got := NewProblem(err); got.Extensions["stack"] != nil {
+		t.Errorf("NewProblem() exposed the stack without ExposeStack")
+	}
+
+	w := httptest.NewRecorder()
+	WriteError(w, err, ExposeStack(true))
+
+	var got struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("WriteError() wrote invalid json %s, %v", w.Body.String(), err)
+	}
+	if !reflect.DeepEqual(got.Stack, err.GetStack()) {
+		t.Errorf("WriteError() stack = %v, want %v", got.Stack, err.GetStack())
+	}
+}
+
+func TestWriteError_ExposeParents(t *testing.T) {
+	err := bear.Wrap(bear.Wrap(bear.New(bear.WithMsg("root"))), bear.WithCode(BadGateway))
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantStack bool
+	}{
+		{"parents only", []Option{ExposeParents(true)}, false},
+		{"parents and stack", []Option{ExposeParents(true), ExposeStack(true)}, true},
+		{"debug", []Option{Debug(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, err, tt.opts...)
+
+			type jsonParent struct {
+				Msg     string       `json:"msg"`
+				Stack   []string     `json:"stack"`
+				Parents []jsonParent `json:"parents"`
+			}
+			var got struct {
+				Parents []jsonParent `json:"parents"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("WriteError() wrote invalid json %s, %v", w.Body.String(), err)
+			}
+
+			if len(got.Parents) != 1 || len(got.Parents[0].Parents) != 1 || got.Parents[0].Parents[0].Msg != "root" {
+				t.Fatalf("WriteError() parents = %s, want the full parent tree", w.Body.String())
+			}
+			for _, parent := range []jsonParent{got.Parents[0], got.Parents[0].Parents[0]} {
+				if hasStack := len(parent.Stack) > 0; hasStack != tt.wantStack {
+					t.Errorf("WriteError() parent stack exposed = %v, want %v", hasStack, tt.wantStack)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteError_Logger(t *testing.T) {
+	var logged *bear.Error
+	w := httptest.NewRecorder()
+	WriteError(w, errors.New("std error"), WithLogger(func(e *bear.Error) {
+		logged = e
+	}))
+
+	if logged == nil {
+		t.Fatalf("WriteError() did not call the logger")
+	}
+
+	var got struct {
+		Instance string `json:"instance"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("WriteError() wrote invalid json %s, %v", w.Body.String(), err)
+	}
+	if got.Instance != logged.GetID() {
+		t.Errorf("WriteError() instance = %s, want logged id %s", got.Instance, logged.GetID())
+	}
+}
+
+func TestWriteError_Nil(t *testing.T) {
+	var logged bool
+	w := httptest.NewRecorder()
+	WriteError(w, nil, WithLogger(func(e *bear.Error) {
+		logged = true
+	}))
+
+	if logged || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("WriteError() wrote a response for a nil error, %s", w.Body.String())
+	}
+	if got := NewProblem(nil); !reflect.DeepEqual(got, Problem{}) {
+		t.Errorf("NewProblem() = %v, want an empty Problem", got)
+	}
+}
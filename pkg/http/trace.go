@@ -0,0 +1,187 @@
+package bearhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bjatkin/bear"
+)
+
+// TraceParentHeader is the W3C trace context header that identifies the trace a request is part of
+// https://www.w3.org/TR/trace-context/#traceparent-header
+const TraceParentHeader = "traceparent"
+
+// TraceParent is a parsed W3C traceparent header
+type TraceParent struct {
+	// Version is the version of the header, only version 0 is fully supported
+	Version byte
+	// TraceID is the 32 character hex id of the trace
+	TraceID string
+	// ParentID is the 16 character hex id of the caller's span
+	ParentID string
+	// Flags are the trace flags, see Sampled
+	Flags byte
+}
+
+// ParseTraceParent parses a W3C traceparent header e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceParent(header string) (TraceParent, error) {
+	header = strings.TrimSpace(header)
+
+	// headers with a later version may have extra fields after the flags
+	if len(header) < 55 || (len(header) > 55 && header[55] != '-') {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, wrong length", header)
+	}
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, missing separator", header)
+	}
+
+	version, err := parseHexByte(header[0:2])
+	if err != nil || version == 0xff {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, bad version", header)
+	}
+	if version == 0 && len(header) != 55 {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, wrong length", header)
+	}
+
+	traceID, parentID := header[3:35], header[36:52]
+	if !isLowerHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, bad trace id", header)
+	}
+	if !isLowerHex(parentID) || parentID == strings.Repeat("0", 16) {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, bad parent id", header)
+	}
+
+	flags, err := parseHexByte(header[53:55])
+	if err != nil {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q, bad flags", header)
+	}
+
+	return TraceParent{
+		Version:  version,
+		TraceID:  traceID,
+		ParentID: parentID,
+		Flags:    flags,
+	}, nil
+}
+
+// Sampled returns true if the caller may have recorded the trace
+func (t TraceParent) Sampled() bool {
+	return t.Flags&0x01 == 0x01
+}
+
+// String formats the traceparent as a version 0 header
+func (t TraceParent) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", t.TraceID, t.ParentID, t.Flags)
+}
+
+// parentIDKey is the key for the caller's span id stored in a context
+type parentIDKey struct{}
+
+// TraceContext returns a copy of the request's context with the trace from the traceparent header,
+// so errors created with bear.NewCtx or bear.WrapCtx have the same trace id as the request.
+// A new span id is created for this service, the parent id of the header is the caller's span
+// and can be read with ParentIDFromContext. The request's context is returned if the header is missing or invalid
+func TraceContext(r *http.Request) context.Context {
+	tp, err := ParseTraceParent(r.Header.Get(TraceParentHeader))
+	if err != nil {
+		return r.Context()
+	}
+
+	ctx := context.WithValue(r.Context(), parentIDKey{}, tp.ParentID)
+	return bear.ContextWithTrace(ctx, tp.TraceID, newSpanID())
+}
+
+// ParentIDFromContext returns the caller's span id that TraceContext read from the traceparent header
+func ParentIDFromContext(ctx context.Context) (string, bool) {
+	parentID, ok := ctx.Value(parentIDKey{}).(string)
+	return parentID, ok
+}
+
+// newSpanID creates a random 16 character hex span id
+func newSpanID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand should never fail, but the span id still needs to be unique if it does
+		binary.BigEndian.PutUint64(b[:], uint64(time.Now().UnixNano()))
+	}
+	// an all zero span id is invalid
+	if b == [8]byte{} {
+		b[7] = 1
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+// SetTraceParent sets the traceparent header using the trace stored in the context, the trace is marked as sampled.
+// The context's span id is sent as the parent id, so it should be the span of this service (see TraceContext).
+// Nothing is set if the context does not have a valid trace
+func SetTraceParent(ctx context.Context, h http.Header) {
+	traceID, spanID, ok := bear.TraceFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	tp := TraceParent{TraceID: traceID, ParentID: spanID, Flags: 0x01}
+	if _, err := ParseTraceParent(tp.String()); err != nil {
+		return
+	}
+
+	h.Set(TraceParentHeader, tp.String())
+}
+
+// withTrace returns the request with the trace from the traceparent header added to its context
+func withTrace(r *http.Request) *http.Request {
+	if _, _, ok := bear.TraceFromContext(r.Context()); ok {
+		return r
+	}
+
+	ctx := TraceContext(r)
+	if ctx == r.Context() {
+		return r
+	}
+	return r.WithContext(ctx)
+}
+
+// findTrace returns the first trace id in the error tree
+func findTrace(err error) (string, bool) {
+	if berr, ok := err.(*bear.Error); ok {
+		if traceID, ok := berr.GetTraceID(); ok {
+			return traceID, true
+		}
+	}
+
+	for _, parent := range bear.Unwrap(err) {
+		if traceID, ok := findTrace(parent); ok {
+			return traceID, true
+		}
+	}
+
+	return "", false
+}
+
+// parseHexByte parses a two character lowercase hex string
+func parseHexByte(s string) (byte, error) {
+	if !isLowerHex(s) {
+		return 0, fmt.Errorf("invalid hex %q", s)
+	}
+
+	var b [1]byte
+	_, err := hex.Decode(b[:], []byte(s))
+	return b[0], err
+}
+
+// isLowerHex returns true if s only contains lowercase hex characters
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package bearhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    TraceParent
+		wantErr bool
+	}{
+		{
+			"valid header",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", Flags: 1},
+			false,
+		},
+		{
+			"not sampled",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7"},
+			false,
+		},
+		{
+			"future version with extra fields",
+			"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			TraceParent{Version: 1, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", Flags: 1},
+			false,
+		},
+		{
+			"version 0 with extra fields",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			TraceParent{},
+			true,
+		},
+		{
+			"invalid version",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			TraceParent{},
+			true,
+		},
+		{
+			"zero trace id",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			TraceParent{},
+			true,
+		},
+		{
+			"zero parent id",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			TraceParent{},
+			true,
+		},
+		{
+			"uppercase hex",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			TraceParent{},
+			true,
+		},
+		{
+			"missing separator",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7-01",
+			TraceParent{},
+			true,
+		},
+		{
+			"empty",
+			"",
+			TraceParent{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceParent(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTraceParent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTraceParent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceParent_String(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tp, err := ParseTraceParent(header)
+	if err != nil {
+		t.Fatalf("ParseTraceParent() error = %v", err)
+	}
+
+	if got := tp.String(); got != header {
+		t.Errorf("TraceParent.String() = %s, want %s", got, header)
+	}
+	if !tp.Sampled() {
+		t.Errorf("TraceParent.Sampled() = false, want true")
+	}
+}
+
+func TestSetTraceParent(t *testing.T) {
+	h := http.Header{}
+	SetTraceParent(context.Background(), h)
+	if got := h.Get(TraceParentHeader); got != "" {
+		t.Errorf("SetTraceParent() set %s without a trace", got)
+	}
+
+	SetTraceParent(bear.ContextWithTrace(context.Background(), "invalid", "ids"), h)
+	if got := h.Get(TraceParentHeader); got != "" {
+		t.Errorf("SetTraceParent() set an invalid trace %s", got)
+	}
+
+	ctx := bear.ContextWithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	SetTraceParent(ctx, h)
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := h.Get(TraceParentHeader); got != want {
+		t.Errorf("SetTraceParent() = %s, want %s", got, want)
+	}
+}
+
+func TestHandle_Trace(t *testing.T) {
+	var created *bear.Error
+	var ctx context.Context
+	handler := Handle(func(w http.ResponseWriter, r *http.Request) error {
+		ctx = r.Context()
+		created = bear.NewCtx(ctx, bear.WithCode(BadRequest))
+		return created
+	})
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if traceID, _ := created.GetTraceID(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("NewCtx() trace id = %s, want the traceparent trace id", traceID)
+	}
+	spanID, _ := created.GetSpanID()
+	if len(spanID) != 16 || !isLowerHex(spanID) || spanID == "00f067aa0ba902b7" {
+		t.Errorf("NewCtx() span id = %s, want a new span id for this service", spanID)
+	}
+	if parentID, _ := ParentIDFromContext(ctx); parentID != "00f067aa0ba902b7" {
+		t.Errorf("ParentIDFromContext() = %s, want the traceparent parent id", parentID)
+	}
+
+	// downstream services should see this service's span as their parent
+	h := http.Header{}
+	SetTraceParent(ctx, h)
+	if got, want := h.Get(TraceParentHeader), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+spanID+"-01"; got != want {
+		t.Errorf("SetTraceParent() = %s, want %s", got, want)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Handle() returned invalid json, %v", err)
+	}
+	want := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Bad Request",
+		"status":   float64(400),
+		"instance": created.GetID(),
+		"traceId":  "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Handle() = %v, want %v", got, want)
+	}
+}
+
+func TestMiddleware_Trace(t *testing.T) {
+	var reported *bear.Error
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("panicking")
+	}), WithReporter(func(r *http.Request, err *bear.Error) {
+		reported = err
+	}))
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if traceID, _ := reported.GetTraceID(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Middleware() trace id = %s, want the traceparent trace id", traceID)
+	}
+}
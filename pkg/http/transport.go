@@ -0,0 +1,237 @@
+package bearhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/bjatkin/bear"
+)
+
+const (
+	// DefaultMaxBodySize is the default number of bytes read from an error response
+	DefaultMaxBodySize = 1 << 20
+	// DefaultMaxDepth is the default number of parent levels kept from a remote error
+	DefaultMaxDepth = 10
+	// RemoteOriginTag is the tag added to remote errors with the host they came from
+	RemoteOriginTag = "remoteOrigin"
+)
+
+// RemoteErr is the type of the errors returned by Transport, the remote error is the error's parent
+var RemoteErr = bear.NewType("Remote Error")
+
+// Transport is an http.RoundTripper that converts error responses into bear errors.
+// If a response has a 4xx or 5xx status, the body is decoded as a bear json error or a problem+json
+// error and a RemoteErr wrapping the remote error is returned instead of the response.
+// If the body can't be decoded the RemoteErr has no parent and the reason is added as the remoteError tag.
+// Other responses, including redirects, are returned as is. The trace stored in the request's context
+// is sent using the traceparent header if the request does not already have one
+type Transport struct {
+	// Base is the RoundTripper used to make the requests, http.DefaultTransport is used if Base is nil
+	Base http.RoundTripper
+	// MaxBodySize is the max number of bytes read from an error response, DefaultMaxBodySize is used if it's 0.
+	// Bodies that are larger are not decoded
+	MaxBodySize int64
+	// MaxDepth is the max number of parent levels kept from the remote error, DefaultMaxDepth is used if it's 0.
+	// Parents that are deeper are dropped
+	MaxDepth int
+	// Opts are added to every RemoteErr, by default the errors have the BadGateway code
+	Opts []bear.ErrOption
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(TraceParentHeader) == "" {
+		if _, _, ok := bear.TraceFromContext(req.Context()); ok {
+			// a RoundTripper must not modify the request so a copy is used
+			req = req.Clone(req.Context())
+			SetTraceParent(req.Context(), req.Header)
+		}
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < BadRequest || resp.StatusCode > 599 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	opts := []bear.ErrOption{
+		bear.WithErrType(RemoteErr),
+		bear.WithCode(BadGateway),
+		bear.WithMsg(fmt.Sprintf("%s %s returned %d %s", req.Method, req.URL.Redacted(), resp.StatusCode, http.StatusText(resp.StatusCode))),
+		bear.WithTag("remoteStatus", resp.StatusCode),
+	}
+	opts = append(opts, t.Opts...)
+
+	remote, err := t.remoteError(resp)
+	if err != nil {
+		return nil, bear.NewCtx(req.Context(), append(opts, bear.WithTag("remoteError", err.Error()))...)
+	}
+
+	remote.Add(bear.WithTag(RemoteOriginTag, req.URL.Host))
+	return nil, bear.WrapCtx(req.Context(), remote, opts...)
+}
+
+// base returns the RoundTripper used to make requests
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// remoteError decodes the bear or problem+json error in the response body
+func (t *Transport) remoteError(resp *http.Response) (*bear.Error, error) {
+	maxBodySize := t.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	maxDepth := t.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the error response, %w", err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, fmt.Errorf("the error response is larger than %d bytes", maxBodySize)
+	}
+
+	var members map[string]json.RawMessage
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == ProblemContentType {
+		members, err = problemToBear(body, resp.StatusCode)
+	} else {
+		err = json.Unmarshal(body, &members)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the error response is not a json error, %w", err)
+	}
+	// ParseJSON ignores unknown fields so other json errors would become empty bear errors,
+	// the body is kept in the error message instead so it's not lost
+	if !hasBearMembers(members) {
+		return nil, fmt.Errorf("the error response is not a bear error, %s", body)
+	}
+
+	if err := limitDepth(members, maxDepth); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	return bear.ParseJSON(raw)
+}
+
+// bearMembers are the json members that identify a bear json error
+var bearMembers = []string{"id", "msg", "errType", "code", "parents"}
+
+// hasBearMembers returns true if the json error has at least one of the bear json error members
+func hasBearMembers(members map[string]json.RawMessage) bool {
+	for _, name := range bearMembers {
+		if _, ok := members[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// problemToBear converts a problem+json error into the members of a bear json error
+func problemToBear(body []byte, status int) (map[string]json.RawMessage, error) {
+	var p struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid problem, %w", err)
+	}
+	if p.Status == 0 {
+		p.Status = status
+	}
+
+	var problem map[string]json.RawMessage
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return nil, fmt.Errorf("invalid problem, %w", err)
+	}
+
+	members := map[string]interface{}{"code": p.Status}
+	if p.Instance != "" {
+		members["id"] = p.Instance
+	}
+	if p.Detail != "" {
+		members["msg"] = p.Detail
+	}
+	// the title is the status text unless the remote error had a type, see NewProblem
+	if p.Title != "" && (p.Title != http.StatusText(p.Status) || (p.Type != "" && p.Type != "about:blank")) {
+		members["errType"] = p.Title
+	}
+
+	tags := make(map[string]json.RawMessage)
+	for name, raw := range problem {
+		if _, ok := problemMembers[name]; ok {
+			continue
+		}
+
+		switch name {
+		case "stack":
+			// the stack can't be added to a bear error since it has a different format
+		case "labels", "parents", "traceId":
+			members[name] = raw
+		default:
+			tags[name] = raw
+		}
+	}
+	if len(tags) > 0 {
+		members["tags"] = tags
+	}
+
+	raw, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+
+	var converted map[string]json.RawMessage
+	err = json.Unmarshal(raw, &converted)
+	return converted, err
+}
+
+// limitDepth drops any parents that are deeper than depth
+func limitDepth(members map[string]json.RawMessage, depth int) error {
+	raw, ok := members["parents"]
+	if !ok {
+		return nil
+	}
+	if depth == 0 {
+		delete(members, "parents")
+		return nil
+	}
+
+	var parents []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &parents); err != nil {
+		return fmt.Errorf("invalid parents, %w", err)
+	}
+
+	for _, parent := range parents {
+		if err := limitDepth(parent, depth-1); err != nil {
+			return err
+		}
+	}
+
+	limited, err := json.Marshal(parents)
+	if err != nil {
+		return err
+	}
+	members["parents"] = limited
+	return nil
+}
@@ -0,0 +1,261 @@
+package bearhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+// remoteServer starts a server that responds with the status, content type and body
+func remoteServer(t *testing.T, status int, contentType, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// depth returns the number of bear error levels in the tree
+func depth(err error) int {
+	deepest := 0
+	for _, parent := range bear.Unwrap(err) {
+		if d := depth(parent); d > deepest {
+			deepest = d
+		}
+	}
+	return deepest + 1
+}
+
+func TestTransport(t *testing.T) {
+	dbErr := bear.NewType("DB Error")
+	nested := bear.Wrap(
+		bear.Wrap(bear.New(bear.WithMsg("level 3"), bear.WithID("3")), bear.WithID("2")),
+		bear.WithErrType(dbErr), bear.WithMsg("query failed"), bear.WithCode(InternalServerError),
+		bear.WithLabels("db"), bear.WithTag("table", "users"), bear.WithID("1"), bear.FmtNoStack(true),
+	)
+
+	problemServer := httptest.NewServer(Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return bear.New(bear.WithErrType(dbErr), bear.WithMsg("user not found"), bear.WithCode(NotFound),
+			bear.WithID("problem-id"), bear.WithLabels("users"), bear.WithTag("userID", "42"))
+	}, WithTypeURI("https://example.com/errors/")))
+	defer problemServer.Close()
+
+	type want struct {
+		remote   bool
+		errType  bear.ErrType
+		msg      string
+		id       string
+		code     int
+		labels   []string
+		tags     map[string]interface{}
+		depth    int
+		errorTag string
+	}
+	tests := []struct {
+		name      string
+		url       string
+		transport *Transport
+		want      want
+	}{
+		{
+			"bear json error",
+			remoteServer(t, InternalServerError, "application/json", nested.Error()).URL,
+			&Transport{},
+			want{
+				remote:  true,
+				errType: dbErr,
+				msg:     "query failed",
+				id:      "1",
+				code:    InternalServerError,
+				labels:  []string{"db"},
+				tags:    map[string]interface{}{"table": "users"},
+				depth:   3,
+			},
+		},
+		{
+			"problem json error",
+			problemServer.URL,
+			&Transport{},
+			want{
+				remote:  true,
+				errType: dbErr,
+				msg:     "user not found",
+				id:      "problem-id",
+				code:    NotFound,
+				labels:  []string{"users"},
+				tags:    map[string]interface{}{"userID": "42"},
+				depth:   1,
+			},
+		},
+		{
+			"untyped problem",
+			remoteServer(t, Conflict, ProblemContentType, `{"type":"about:blank","title":"Conflict","status":409}`).URL,
+			&Transport{},
+			want{
+				remote: true,
+				code:   Conflict,
+				tags:   map[string]interface{}{},
+				depth:  1,
+			},
+		},
+		{
+			"depth limit",
+			remoteServer(t, InternalServerError, "application/json", nested.Error()).URL,
+			&Transport{MaxDepth: 1},
+			want{
+				remote:  true,
+				errType: dbErr,
+				msg:     "query failed",
+				id:      "1",
+				code:    InternalServerError,
+				labels:  []string{"db"},
+				tags:    map[string]interface{}{"table": "users"},
+				depth:   2,
+			},
+		},
+		{
+			"size limit",
+			remoteServer(t, InternalServerError, "application/json", nested.Error()).URL,
+			&Transport{MaxBodySize: 10},
+			want{
+				errorTag: "larger than 10 bytes",
+			},
+		},
+		{
+			"invalid json",
+			remoteServer(t, BadGateway, "text/plain", "upstream unavailable").URL,
+			&Transport{},
+			want{
+				errorTag: "not a json error",
+			},
+		},
+		{
+			"other json error",
+			remoteServer(t, NotFound, "application/json", `{"error":"user 42 not found"}`).URL,
+			&Transport{},
+			want{
+				errorTag: `{"error":"user 42 not found"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.transport}
+			resp, err := client.Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("Transport.RoundTrip() error = nil, want a remote error")
+			}
+
+			var berr *bear.Error
+			if !errors.As(err, &berr) || !bear.Is(berr, RemoteErr) {
+				t.Fatalf("Transport.RoundTrip() error = %v, want a RemoteErr", err)
+			}
+			if code, _ := berr.GetCode(); code != BadGateway {
+				t.Errorf("Transport.RoundTrip() code = %d, want %d", code, BadGateway)
+			}
+			errorTag, _ := berr.GetTag("remoteError")
+			if got, _ := errorTag.(string); (tt.want.errorTag == "" && got != "") || !strings.Contains(got, tt.want.errorTag) {
+				t.Errorf("Transport.RoundTrip() remoteError tag = %s, want it to contain %q", got, tt.want.errorTag)
+			}
+
+			parents := berr.Unwrap()
+			if !tt.want.remote {
+				if len(parents) != 0 {
+					t.Errorf("Transport.RoundTrip() parents = %v, want none", parents)
+				}
+				return
+			}
+			if len(parents) != 1 {
+				t.Fatalf("Transport.RoundTrip() parents = %v, want the remote error", parents)
+			}
+
+			remote := parents[0].(*bear.Error)
+			if errType, _ := remote.GetErrType(); errType != tt.want.errType {
+				t.Errorf("remote error type = %s, want %s", errType, tt.want.errType)
+			}
+			if msg, _ := remote.GetMsg(); msg != tt.want.msg {
+				t.Errorf("remote error msg = %s, want %s", msg, tt.want.msg)
+			}
+			if id := remote.GetID(); id != tt.want.id {
+				t.Errorf("remote error id = %s, want %s", id, tt.want.id)
+			}
+			if code, _ := remote.GetCode(); code != tt.want.code {
+				t.Errorf("remote error code = %d, want %d", code, tt.want.code)
+			}
+			if labels := remote.GetLabels(); !reflect.DeepEqual(labels, tt.want.labels) {
+				t.Errorf("remote error labels = %v, want %v", labels, tt.want.labels)
+			}
+
+			tags := remote.GetTags()
+			if origin := tags[RemoteOriginTag]; origin != strings.TrimPrefix(tt.url, "http://") {
+				t.Errorf("remote error origin = %v, want %s", origin, tt.url)
+			}
+			delete(tags, RemoteOriginTag)
+			if !reflect.DeepEqual(tags, tt.want.tags) {
+				t.Errorf("remote error tags = %v, want %v", tags, tt.want.tags)
+			}
+
+			if d := depth(remote); d != tt.want.depth {
+				t.Errorf("remote error depth = %d, want %d", d, tt.want.depth)
+			}
+		})
+	}
+}
+
+func TestTransport_Success(t *testing.T) {
+	server := remoteServer(t, http.StatusOK, "text/plain", "ok")
+	client := &http.Client{Transport: &Transport{}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Transport.RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Transport.RoundTrip() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestTransport_Trace(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(TraceParentHeader)
+		w.WriteHeader(InternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := bear.ContextWithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf("http.NewRequestWithContext() error = %v", err)
+	}
+
+	_, err = (&Transport{}).RoundTrip(req)
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; got != want {
+		t.Errorf("Transport.RoundTrip() traceparent = %s, want %s", got, want)
+	}
+	if req.Header.Get(TraceParentHeader) != "" {
+		t.Errorf("Transport.RoundTrip() modified the request headers")
+	}
+
+	var berr *bear.Error
+	if !errors.As(err, &berr) {
+		t.Fatalf("Transport.RoundTrip() error = %v, want a bear error", err)
+	}
+	if traceID, _ := berr.GetTraceID(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Transport.RoundTrip() trace id = %s, want the context trace id", traceID)
+	}
+}
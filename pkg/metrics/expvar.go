@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"expvar"
+	"math"
+)
+
+// Var returns an expvar.Var with the current value of every metric in the registry.
+// The var is a json object keyed by metric name, metrics without labels are a single value
+// and metrics with labels are an object keyed by their labels e.g. {"errors":{"{table=\"users\"}":2}}.
+// Histograms are an object with the count and sum of the observed values
+func (r *Registry) Var() expvar.Var {
+	return expvar.Func(func() interface{} {
+		vars := make(map[string]interface{})
+		for _, f := range r.snapshot() {
+			if len(f.metrics) == 0 {
+				continue
+			}
+
+			if reg, ok := f.metrics[""]; ok && len(f.metrics) == 1 {
+				vars[f.name] = expvarValue(reg.metric)
+				continue
+			}
+
+			values := make(map[string]interface{}, len(f.metrics))
+			for key, reg := range f.metrics {
+				values[key] = expvarValue(reg.metric)
+			}
+			vars[f.name] = values
+		}
+
+		return vars
+	})
+}
+
+// GroupVar returns an expvar.Var with the sum of the metrics with the name grouped by the value of the label key,
+// see GroupMetrics. Metrics without the label are grouped under the empty string
+func (r *Registry) GroupVar(name, key string) expvar.Var {
+	return expvar.Func(func() interface{} {
+		var metrics []*Metric
+		for _, f := range r.snapshot() {
+			if f.name != name {
+				continue
+			}
+			for _, reg := range f.metrics {
+				metrics = append(metrics, withRegisteredLabels(reg))
+			}
+		}
+
+		groups := GroupMetrics(key, metrics...)
+		values := make(map[string]interface{}, len(groups))
+		for group, value := range groups {
+			values[group] = jsonFloat(value)
+		}
+		return values
+	})
+}
+
+// Publish publishes the registry as an expvar with the name, see Var.
+// Like expvar.Publish it panics if the name is already in use
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, r.Var())
+}
+
+// withRegisteredLabels returns the metric if it already has the registered labels,
+// otherwise it returns a labeled snapshot of the metric
+func withRegisteredLabels(reg *registered) *Metric {
+	if reg.metric.labels.String() == reg.labels.String() {
+		return reg.metric
+	}
+
+	snapshot := reg.metric.Snapshot()
+	snapshot.labels = reg.labels
+	return snapshot
+}
+
+// expvarValue returns the value of the metric in a form that can be marshaled to json
+func expvarValue(m *Metric) interface{} {
+	if m.hist != nil {
+		return map[string]interface{}{
+			"count": m.GetCount(),
+			"sum":   jsonFloat(m.GetFloat()),
+		}
+	}
+	if m.float {
+		return jsonFloat(m.GetFloat())
+	}
+	return m.GetValue()
+}
+
+// jsonFloat returns the float as a string if it can not be represented in json
+func jsonFloat(f float64) interface{} {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return formatFloat(f)
+	}
+	return f
+}
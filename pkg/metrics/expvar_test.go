@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// published counts the published test vars so each var gets a unique name even when the tests are run more than once
+var published int64
+
+// expvarName returns a unique expvar name for the test
+func expvarName(name string) string {
+	return fmt.Sprintf("%s_%d", name, atomic.AddInt64(&published, 1))
+}
+
+// readExpvar reads the published var with the name from the expvar handler
+func readExpvar(t *testing.T, name string) interface{} {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+
+	var vars map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("expvar.Handler() returned invalid json, %v", err)
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Errorf("expvar.Handler() is missing memstats")
+	}
+	return vars[name]
+}
+
+func TestRegistry_Publish(t *testing.T) {
+	r := NewRegistry()
+	name := expvarName("test_registry")
+	r.Publish(name)
+
+	r.Counter("requests", nil).Add(3)
+	r.Counter("errors", Labels{"table": "users"}).Add(2)
+	r.Counter("errors", Labels{"table": "orders"}).Add(1)
+	r.FloatGauge("load", nil).SetFloat(math.Inf(1))
+	r.Histogram("latency", nil, 1).Observe(0.5)
+
+	want := map[string]interface{}{
+		"requests": 3.0,
+		"errors": map[string]interface{}{
+			`{table="users"}`:  2.0,
+			`{table="orders"}`: 1.0,
+		},
+		"load":    "+Inf",
+		"latency": map[string]interface{}{"count": 1.0, "sum": 0.5},
+	}
+	if got := readExpvar(t, name); !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.Publish() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistry_GroupVar(t *testing.T) {
+	r := NewRegistry()
+	name := expvarName("test_group")
+	expvar.Publish(name, r.GroupVar("errors", "table"))
+
+	r.Counter("errors", Labels{"table": "users", "db": "primary"}).Add(2)
+	r.Counter("errors", Labels{"table": "users", "db": "replica"}).Add(3)
+	r.Counter("errors", nil).Add(1)
+	r.Counter("other", Labels{"table": "users"}).Add(10)
+	if err := r.Register(NewMetric("errors"), Labels{"table": "orders"}); err != nil {
+		t.Fatalf("Registry.Register() error = %v", err)
+	}
+
+	want := map[string]interface{}{"users": 5.0, "orders": 0.0, "": 1.0}
+	if got := readExpvar(t, name); !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.GroupVar() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the default histogram buckets, they're meant to track latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// histogram tracks the number of observations that fall into each bucket
+type histogram struct {
+	// bounds are the sorted upper bounds of each bucket
+	bounds []float64
+	// counts are the number of observations in each bucket, the last count is the +Inf bucket
+	counts []uint64
+}
+
+// Bucket is a cumulative histogram bucket
+type Bucket struct {
+	// UpperBound is the inclusive upper bound of the bucket
+	UpperBound float64
+	// Count is the number of observations less than or equal to the upper bound
+	Count uint64
+}
+
+// NewHistogram creates a new histogram metric, the buckets are the upper bounds of each bucket.
+// If no buckets are given DefaultBuckets are used. The value of a histogram is the sum of all
+// the observed values
+func NewHistogram(name string, buckets ...float64) *Metric {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	bounds := make([]float64, 0, len(buckets))
+	for _, bound := range buckets {
+		if !math.IsInf(bound, 1) && !math.IsNaN(bound) {
+			bounds = append(bounds, bound)
+		}
+	}
+	sort.Float64s(bounds)
+
+	return &Metric{
+		name:  name,
+		float: true,
+		kind:  KindHistogram,
+		hist: &histogram{
+			bounds: bounds,
+			counts: make([]uint64, len(bounds)+1),
+		},
+	}
+}
+
+// Observe adds a value to the histogram, for other metrics the value is added to the metric
+func (m *Metric) Observe(value float64) {
+	if m.hist == nil {
+		m.AddFloat(value)
+		return
+	}
+
+	i := sort.SearchFloat64s(m.hist.bounds, value)
+	atomic.AddUint64(&m.hist.counts[i], 1)
+	m.addFloat(value)
+}
+
+// GetCount returns the number of values observed by the histogram, other metrics return 0
+func (m *Metric) GetCount() uint64 {
+	if m.hist == nil {
+		return 0
+	}
+
+	var count uint64
+	for i := range m.hist.counts {
+		count += atomic.LoadUint64(&m.hist.counts[i])
+	}
+	return count
+}
+
+// GetBuckets returns the cumulative buckets of the histogram, the +Inf bucket is not included
+// since it's count is always the same as GetCount. Other metrics return nil
+func (m *Metric) GetBuckets() []Bucket {
+	if m.hist == nil {
+		return nil
+	}
+
+	buckets := make([]Bucket, len(m.hist.bounds))
+	var count uint64
+	for i, bound := range m.hist.bounds {
+		count += atomic.LoadUint64(&m.hist.counts[i])
+		buckets[i] = Bucket{UpperBound: bound, Count: count}
+	}
+	return buckets
+}
+
+// MergeHistograms merges the histograms into a new histogram with the given name
+// all the histograms must have the same buckets
+func MergeHistograms(name string, histograms ...*Metric) (*Metric, error) {
+	if len(histograms) == 0 {
+		return NewHistogram(name), nil
+	}
+
+	for _, h := range histograms {
+		if h.hist == nil {
+			return nil, fmt.Errorf("can not merge metric %s, it is not a histogram", h.name)
+		}
+	}
+
+	merged := NewHistogram(name, histograms[0].hist.bounds...)
+	for _, h := range histograms {
+		if !equalBounds(merged.hist.bounds, h.hist.bounds) {
+			return nil, fmt.Errorf("can not merge histogram %s, the buckets do not match", h.name)
+		}
+
+		for i := range h.hist.counts {
+			atomic.AddUint64(&merged.hist.counts[i], atomic.LoadUint64(&h.hist.counts[i]))
+		}
+		merged.addFloat(h.floatValue())
+	}
+
+	return merged, nil
+}
+
+// equalBounds returns true if the bucket bounds are the same
+func equalBounds(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonBucket is the json representation of a Bucket
+type jsonBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// jsonBuckets converts the histogram buckets to their json representation
+func (m *Metric) jsonBuckets() []jsonBucket {
+	buckets := m.GetBuckets()
+	jbuckets := make([]jsonBucket, len(buckets))
+	for i, bucket := range buckets {
+		jbuckets[i] = jsonBucket(bucket)
+	}
+	return jbuckets
+}
+
+// newHistogramFromJSON rebuilds a histogram from its json representation
+func newHistogramFromJSON(name string, sum float64, count uint64, buckets []jsonBucket) (*Metric, error) {
+	bounds := make([]float64, len(buckets))
+	for i, bucket := range buckets {
+		bounds[i] = bucket.UpperBound
+	}
+
+	m := NewHistogram(name, bounds...)
+	// an empty set of buckets is a valid histogram, so the default buckets should not be used
+	if len(bounds) == 0 {
+		m.hist.bounds = nil
+		m.hist.counts = make([]uint64, 1)
+	}
+	if !equalBounds(m.hist.bounds, bounds) {
+		return nil, fmt.Errorf("invalid histogram %s, the buckets must be sorted", name)
+	}
+
+	var last uint64
+	for i, bucket := range buckets {
+		if bucket.Count < last {
+			return nil, fmt.Errorf("invalid histogram %s, the bucket counts must be cumulative", name)
+		}
+		m.hist.counts[i] = bucket.Count - last
+		last = bucket.Count
+	}
+
+	if count < last {
+		return nil, fmt.Errorf("invalid histogram %s, the count is less than the bucket counts", name)
+	}
+	m.hist.counts[len(buckets)] = count - last
+	// SetFloat ignores histograms so the sum is stored directly
+	atomic.StoreUint64(&m.fmetric, math.Float64bits(sum))
+
+	return m, nil
+}
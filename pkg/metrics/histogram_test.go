@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogram("latency", 1, 0.1, 0.5)
+	for _, value := range []float64{0.05, 0.1, 0.3, 0.7, 2, 5} {
+		h.Observe(value)
+	}
+
+	wantBuckets := []Bucket{
+		{UpperBound: 0.1, Count: 2},
+		{UpperBound: 0.5, Count: 3},
+		{UpperBound: 1, Count: 4},
+	}
+	if got := h.GetBuckets(); !reflect.DeepEqual(got, wantBuckets) {
+		t.Errorf("Histogram.GetBuckets() = %v, want %v", got, wantBuckets)
+	}
+	if got := h.GetCount(); got != 6 {
+		t.Errorf("Histogram.GetCount() = %d, want 6", got)
+	}
+	if got := h.GetFloat(); got < 8.149 || got > 8.151 {
+		t.Errorf("Histogram.GetFloat() = %f, want 8.15", got)
+	}
+	if h.GetKind() != KindHistogram {
+		t.Errorf("Histogram.GetKind() = %v, want %v", h.GetKind(), KindHistogram)
+	}
+
+	// set has no effect on histograms but add observes the value
+	h.Set(100)
+	h.Add(1)
+	if got := h.GetCount(); got != 7 {
+		t.Errorf("Histogram.Add() count = %d, want 7", got)
+	}
+}
+
+func TestHistogram_DefaultBuckets(t *testing.T) {
+	h := NewHistogram("latency")
+	if got := len(h.GetBuckets()); got != len(DefaultBuckets) {
+		t.Errorf("NewHistogram() got %d buckets, want %d", got, len(DefaultBuckets))
+	}
+}
+
+func TestHistogram_JSON(t *testing.T) {
+	h := NewHistogram("latency", 0.1, 1)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	raw, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Histogram.MarshalJSON() error = %v", err)
+	}
+
+	want := `{"name":"latency","value":3.55,"count":3,"buckets":[{"le":0.1,"count":1},{"le":1,"count":2}]}`
+	if string(raw) != want {
+		t.Errorf("Histogram.MarshalJSON() = %s, want %s", raw, want)
+	}
+
+	parsed := &Metric{}
+	if err := json.Unmarshal(raw, parsed); err != nil {
+		t.Fatalf("Histogram.UnmarshalJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed.GetBuckets(), h.GetBuckets()) || parsed.GetCount() != h.GetCount() || parsed.GetFloat() != h.GetFloat() {
+		t.Errorf("Histogram.UnmarshalJSON() = %v, want %v", parsed, h)
+	}
+
+	invalid := []string{
+		`{"name":"latency","value":1,"count":1,"buckets":[{"le":1,"count":2},{"le":0.1,"count":3}]}`,
+		`{"name":"latency","value":1,"count":1,"buckets":[{"le":0.1,"count":2},{"le":1,"count":1}]}`,
+		`{"name":"latency","value":1,"count":1,"buckets":[{"le":0.1,"count":2}]}`,
+	}
+	for _, raw := range invalid {
+		if err := json.Unmarshal([]byte(raw), &Metric{}); err == nil {
+			t.Errorf("Histogram.UnmarshalJSON() parsed invalid histogram %s", raw)
+		}
+	}
+}
+
+func TestHistogram_JSONNoBuckets(t *testing.T) {
+	h := NewHistogram("latency", math.Inf(1))
+	h.Observe(2)
+
+	raw, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Histogram.MarshalJSON() error = %v", err)
+	}
+
+	want := `{"name":"latency","value":2,"count":1,"buckets":[]}`
+	if string(raw) != want {
+		t.Errorf("Histogram.MarshalJSON() = %s, want %s", raw, want)
+	}
+
+	parsed := &Metric{}
+	if err := json.Unmarshal(raw, parsed); err != nil {
+		t.Fatalf("Histogram.UnmarshalJSON() error = %v", err)
+	}
+	if len(parsed.GetBuckets()) != 0 || parsed.GetCount() != 1 || parsed.GetFloat() != 2 {
+		t.Errorf("Histogram.UnmarshalJSON() = %v, want %v", parsed, h)
+	}
+}
+
+func TestMergeHistograms(t *testing.T) {
+	a := NewHistogram("a", 1, 2)
+	a.Observe(0.5)
+	a.Observe(1.5)
+	b := NewHistogram("b", 1, 2)
+	b.Observe(3)
+
+	merged, err := MergeHistograms("merged", a, b)
+	if err != nil {
+		t.Fatalf("MergeHistograms() error = %v", err)
+	}
+
+	wantBuckets := []Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}}
+	if got := merged.GetBuckets(); !reflect.DeepEqual(got, wantBuckets) {
+		t.Errorf("MergeHistograms() buckets = %v, want %v", got, wantBuckets)
+	}
+	if merged.GetCount() != 3 || merged.GetFloat() != 5 {
+		t.Errorf("MergeHistograms() = %v, want count 3 and sum 5", merged)
+	}
+
+	if _, err := MergeHistograms("bad", a, NewHistogram("c", 1)); err == nil {
+		t.Errorf("MergeHistograms() merged histograms with different buckets")
+	}
+	if _, err := MergeHistograms("bad", a, NewMetric("d")); err == nil {
+		t.Errorf("MergeHistograms() merged a metric that was not a histogram")
+	}
+}
+
+func TestHistogram_Concurrent(t *testing.T) {
+	h := NewHistogram("latency", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				h.Observe(0.5)
+				_, _ = json.Marshal(h)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := h.GetCount(); got != 10000 {
+		t.Errorf("Histogram.Observe() concurrent count = %d, want 10000", got)
+	}
+}
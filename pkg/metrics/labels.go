@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+)
+
+// Labels are key value pairs that add dimensions to a metric, e.g. {table="users"}
+type Labels map[string]string
+
+// String returns the labels in a consistent order e.g. {a="1",b="2"}
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(l))
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + quoteLabel(l[key])
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Match returns true if every key value pair in match is also in the labels
+func (l Labels) Match(match Labels) bool {
+	for key, value := range match {
+		if got, ok := l[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// copyLabels creates a copy of the labels so the caller can not modify a metric's labels.
+// Empty labels are returned as nil
+func copyLabels(l Labels) Labels {
+	if len(l) == 0 {
+		return nil
+	}
+
+	copied := make(Labels, len(l))
+	for key, value := range l {
+		copied[key] = value
+	}
+	return copied
+}
+
+// WithLabels returns a new metric with the same name and kind as m, the labels are added to the labels of m.
+// The new metric starts with a value of zero, e.g.
+//
+//	dbErrors := metrics.NewMetric("db_errors")
+//	users := dbErrors.WithLabels(metrics.Labels{"table": "users"})
+//	orders := dbErrors.WithLabels(metrics.Labels{"table": "orders"})
+func (m *Metric) WithLabels(labels Labels) *Metric {
+	var labeled *Metric
+	if m.hist != nil {
+		labeled = NewHistogram(m.name, m.hist.bounds...)
+		// a histogram with no buckets should not get the default buckets
+		if len(m.hist.bounds) == 0 {
+			labeled.hist.bounds = nil
+			labeled.hist.counts = make([]uint64, 1)
+		}
+	} else {
+		labeled = &Metric{name: m.name, float: m.float, kind: m.kind}
+	}
+
+	merged := make(Labels, len(m.labels)+len(labels))
+	for key, value := range m.labels {
+		merged[key] = value
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	labeled.labels = copyLabels(merged)
+
+	return labeled
+}
+
+// GetLabels returns a copy of the metric's labels
+func (m *Metric) GetLabels() Labels {
+	return copyLabels(m.labels)
+}
+
+// MatchLabels returns a filter for FilterMetrics that matches metrics with all the labels
+func MatchLabels(labels Labels) func(*Metric) bool {
+	return func(m *Metric) bool {
+		return m.labels.Match(labels)
+	}
+}
+
+// FilterLabels takes a list of metrics and returns only those with all the labels
+func FilterLabels(metrics []*Metric, labels Labels) []*Metric {
+	return FilterMetrics(metrics, MatchLabels(labels))
+}
+
+// AddLabeledMetrics adds the values of the metrics with all the labels together and returns the result
+func AddLabeledMetrics(labels Labels, metrics ...*Metric) float64 {
+	return AddMetrics(FilterLabels(metrics, labels)...)
+}
+
+// GroupMetrics adds the values of the metrics together grouped by the value of the label key.
+// Metrics without the label are grouped under the empty string
+func GroupMetrics(key string, metrics ...*Metric) map[string]float64 {
+	groups := make(map[string]float64)
+	for _, m := range metrics {
+		groups[m.labels[key]] += m.GetFloat()
+	}
+	return groups
+}
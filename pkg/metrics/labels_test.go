@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMetric_WithLabels(t *testing.T) {
+	base := NewFloatGauge("db_errors").WithLabels(Labels{"db": "postgres"})
+	base.SetFloat(3)
+
+	labels := Labels{"table": "users"}
+	users := base.WithLabels(labels)
+	labels["table"] = "changed"
+
+	if users.GetName() != "db_errors" || !users.IsFloat() || users.GetKind() != KindGauge {
+		t.Errorf("Metric.WithLabels() = %v, want a float gauge named db_errors", users)
+	}
+	if users.GetFloat() != 0 {
+		t.Errorf("Metric.WithLabels() value = %v, want 0", users.GetFloat())
+	}
+	want := Labels{"db": "postgres", "table": "users"}
+	if got := users.GetLabels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metric.WithLabels() labels = %v, want %v", got, want)
+	}
+	if got := base.GetLabels(); !reflect.DeepEqual(got, Labels{"db": "postgres"}) {
+		t.Errorf("Metric.WithLabels() modified the original labels, got %v", got)
+	}
+
+	h := NewHistogram("latency", 1, 2).WithLabels(Labels{"route": "/users"})
+	h.Observe(1.5)
+	if got := h.GetBuckets(); !reflect.DeepEqual(got, []Bucket{{1, 0}, {2, 1}}) {
+		t.Errorf("Metric.WithLabels() histogram buckets = %v", got)
+	}
+}
+
+func TestMetric_LabelsString(t *testing.T) {
+	m := NewMetric("db_errors").WithLabels(Labels{"table": "users", "db": "postgres"})
+	m.Add(2)
+
+	want := `[db_errors{db="postgres",table="users"}] 2`
+	if got := m.String(); got != want {
+		t.Errorf("Metric.String() = %v, want %v", got, want)
+	}
+}
+
+func TestMetric_LabelsJSON(t *testing.T) {
+	m := NewMetric("db_errors").WithLabels(Labels{"table": "users"})
+	m.Add(2)
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Metric.MarshalJSON() error = %v", err)
+	}
+	want := `{"name":"db_errors","labels":{"table":"users"},"value":2}`
+	if string(raw) != want {
+		t.Errorf("Metric.MarshalJSON() = %s, want %s", raw, want)
+	}
+
+	got := &Metric{}
+	if err := json.Unmarshal(raw, got); err != nil {
+		t.Fatalf("Metric.UnmarshalJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Metric.UnmarshalJSON() = %v, want %v", got, m)
+	}
+}
+
+func TestFilterLabels(t *testing.T) {
+	users := NewMetric("db_errors").WithLabels(Labels{"table": "users", "db": "postgres"})
+	orders := NewMetric("db_errors").WithLabels(Labels{"table": "orders", "db": "postgres"})
+	cache := NewMetric("cache_errors")
+	users.Add(2)
+	orders.Add(3)
+	cache.Add(5)
+	all := []*Metric{users, orders, cache}
+
+	type args struct {
+		labels Labels
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*Metric
+		wantSum float64
+	}{
+		{
+			"nil labels",
+			args{
+				labels: nil,
+			},
+			all,
+			10,
+		},
+		{
+			"single label",
+			args{
+				labels: Labels{"db": "postgres"},
+			},
+			[]*Metric{users, orders},
+			5,
+		},
+		{
+			"multiple labels",
+			args{
+				labels: Labels{"db": "postgres", "table": "orders"},
+			},
+			[]*Metric{orders},
+			3,
+		},
+		{
+			"no matches",
+			args{
+				labels: Labels{"table": "accounts"},
+			},
+			nil,
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterLabels(all, tt.args.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterLabels() = %v, want %v", got, tt.want)
+			}
+			if got := AddLabeledMetrics(tt.args.labels, all...); got != tt.wantSum {
+				t.Errorf("AddLabeledMetrics() = %v, want %v", got, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestGroupMetrics(t *testing.T) {
+	a := NewMetric("db_errors").WithLabels(Labels{"table": "users"})
+	b := NewMetric("db_errors").WithLabels(Labels{"table": "users", "db": "replica"})
+	c := NewMetric("db_errors").WithLabels(Labels{"table": "orders"})
+	d := NewMetric("db_errors")
+	a.Add(1)
+	b.Add(2)
+	c.Add(3)
+	d.Add(4)
+
+	want := map[string]float64{"users": 3, "orders": 3, "": 4}
+	if got := GroupMetrics("table", a, b, c, d); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupMetrics() = %v, want %v", got, want)
+	}
+}
@@ -3,50 +3,267 @@ package metrics
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
 )
 
-// Metric is a tracker that usese an int to track a value
+// Kind is the kind of metric
+type Kind int
+
+const (
+	// KindCounter is a metric that is incremented or decremented
+	KindCounter Kind = iota
+	// KindGauge is a metric that is set to the current value of something
+	KindGauge
+	// KindHistogram is a metric that tracks the distribution of observed values
+	KindHistogram
+)
+
+// Metric is a tracker that uses either an int or a float64 to track a value
+// all the methods on Metric are safe to use from multiple goroutines
 type Metric struct {
-	name   string
-	metric int
+	// metric and fmetric are first so they're 64 bit aligned for the atomic operations
+	metric  int64
+	fmetric uint64 // the bits of a float64, see math.Float64bits
+	name    string
+	labels  Labels
+	float   bool
+	kind    Kind
+	hist    *histogram
+	// snapshot is true if the metric is a copy of another metric's value at a point in time
+	snapshot bool
 }
 
-// NewMetric creates a new metric
+// NewMetric creates a new int counter
 func NewMetric(name string) *Metric {
 	return &Metric{
 		name: name,
 	}
 }
 
+// NewFloatMetric creates a new float64 counter
+func NewFloatMetric(name string) *Metric {
+	return &Metric{
+		name:  name,
+		float: true,
+	}
+}
+
+// NewGauge creates a new int gauge
+func NewGauge(name string) *Metric {
+	return &Metric{
+		name: name,
+		kind: KindGauge,
+	}
+}
+
+// NewFloatGauge creates a new float64 gauge
+func NewFloatGauge(name string) *Metric {
+	return &Metric{
+		name:  name,
+		float: true,
+		kind:  KindGauge,
+	}
+}
+
 // Incr adds one to the metric
 func (m *Metric) Incr() {
-	m.metric++
+	m.Add(1)
 }
 
 // Decr subtrcts one from the metric
 func (m *Metric) Decr() {
-	m.metric--
+	m.Add(-1)
 }
 
-// Add adds an abitrary value to the underlying metric
+// Add adds an abitrary value to the underlying metric, histograms observe the value
 func (m *Metric) Add(add int) {
-	m.metric += add
+	if m.hist != nil {
+		m.Observe(float64(add))
+		return
+	}
+	if m.float {
+		m.addFloat(float64(add))
+		return
+	}
+	atomic.AddInt64(&m.metric, int64(add))
+}
+
+// AddFloat adds an abitrary float value to the underlying metric
+// if the metric is an int metric the value is truncated to an int before it's added.
+// Histograms observe the value
+func (m *Metric) AddFloat(add float64) {
+	if m.hist != nil {
+		m.Observe(add)
+		return
+	}
+	if m.float {
+		m.addFloat(add)
+		return
+	}
+	atomic.AddInt64(&m.metric, int64(add))
+}
+
+// Set sets the value of the metric, this is normally used with gauges
+// setting the value of a histogram has no effect
+func (m *Metric) Set(value int) {
+	if m.hist != nil {
+		return
+	}
+	if m.float {
+		atomic.StoreUint64(&m.fmetric, math.Float64bits(float64(value)))
+		return
+	}
+	atomic.StoreInt64(&m.metric, int64(value))
+}
+
+// SetFloat sets the value of the metric, this is normally used with gauges
+// if the metric is an int metric the value is truncated to an int.
+// Setting the value of a histogram has no effect
+func (m *Metric) SetFloat(value float64) {
+	if m.hist != nil {
+		return
+	}
+	if m.float {
+		atomic.StoreUint64(&m.fmetric, math.Float64bits(value))
+		return
+	}
+	atomic.StoreInt64(&m.metric, int64(value))
+}
+
+// addFloat atomically adds the value to the float metric
+func (m *Metric) addFloat(add float64) {
+	for {
+		old := atomic.LoadUint64(&m.fmetric)
+		updated := math.Float64bits(math.Float64frombits(old) + add)
+		if atomic.CompareAndSwapUint64(&m.fmetric, old, updated) {
+			return
+		}
+	}
+}
+
+// intValue atomically loads the int value of the metric
+func (m *Metric) intValue() int {
+	return int(atomic.LoadInt64(&m.metric))
+}
+
+// floatValue atomically loads the float value of the metric
+func (m *Metric) floatValue() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&m.fmetric))
 }
 
 // MarshalJSON implements the marshaler interface
 func (m *Metric) MarshalJSON() ([]byte, error) {
+	if m.hist != nil {
+		return json.Marshal(struct {
+			Name     string       `json:"name"`
+			Labels   Labels       `json:"labels,omitempty"`
+			Value    float64      `json:"value"`
+			Count    uint64       `json:"count"`
+			Buckets  []jsonBucket `json:"buckets"`
+			Snapshot bool         `json:"snapshot,omitempty"`
+		}{
+			m.name,
+			m.labels,
+			m.floatValue(),
+			m.GetCount(),
+			m.jsonBuckets(),
+			m.snapshot,
+		})
+	}
+
 	return json.Marshal(struct {
-		Name  string `json:"name"`
-		Value int    `json:"value"`
+		Name     string      `json:"name"`
+		Labels   Labels      `json:"labels,omitempty"`
+		Value    interface{} `json:"value"`
+		Float    bool        `json:"float,omitempty"`
+		Snapshot bool        `json:"snapshot,omitempty"`
 	}{
 		m.name,
-		m.metric,
+		m.labels,
+		m.value(),
+		m.float,
+		m.snapshot,
 	})
 }
 
+// UnmarshalJSON implements the unmarshaler interface
+// metrics marked as float or with values that can not be parsed as an int will create a float metric
+// and metrics with buckets will create a histogram
+func (m *Metric) UnmarshalJSON(raw []byte) error {
+	var metric struct {
+		Name     string          `json:"name"`
+		Labels   Labels          `json:"labels"`
+		Value    json.RawMessage `json:"value"`
+		Count    uint64          `json:"count"`
+		Buckets  []jsonBucket    `json:"buckets"`
+		Float    bool            `json:"float"`
+		Snapshot bool            `json:"snapshot"`
+	}
+	if err := json.Unmarshal(raw, &metric); err != nil {
+		return err
+	}
+
+	if metric.Buckets != nil {
+		sum, err := strconv.ParseFloat(string(metric.Value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for metric %s, %w", metric.Name, err)
+		}
+
+		h, err := newHistogramFromJSON(metric.Name, sum, metric.Count, metric.Buckets)
+		if err != nil {
+			return err
+		}
+
+		// copy the fields rather than the whole struct so the atomic values are not copied
+		m.name = h.name
+		m.labels = copyLabels(metric.Labels)
+		m.snapshot = metric.Snapshot
+		m.float = h.float
+		m.kind = h.kind
+		m.hist = h.hist
+		atomic.StoreUint64(&m.fmetric, atomic.LoadUint64(&h.fmetric))
+		return nil
+	}
+
+	// the metric is no longer a histogram
+	if m.hist != nil {
+		m.hist = nil
+		m.kind = KindCounter
+	}
+
+	// the float marker keeps whole float values from being parsed as ints
+	if value, err := strconv.Atoi(string(metric.Value)); err == nil && !metric.Float {
+		m.name = metric.Name
+		m.labels = copyLabels(metric.Labels)
+		m.snapshot = metric.Snapshot
+		m.float = false
+		m.Set(value)
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(string(metric.Value), 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for metric %s, %w", metric.Name, err)
+	}
+	m.name = metric.Name
+	m.labels = copyLabels(metric.Labels)
+	m.snapshot = metric.Snapshot
+	m.float = true
+	m.SetFloat(value)
+	return nil
+}
+
 // String implements the stringer interface
 func (m *Metric) String() string {
-	return fmt.Sprintf("[%s] %d", m.name, m.metric)
+	if m.hist != nil {
+		return fmt.Sprintf("[%s%s] count=%d sum=%.4f", m.name, m.labels, m.GetCount(), m.floatValue())
+	}
+	if m.float {
+		return fmt.Sprintf("[%s%s] %.4f", m.name, m.labels, m.floatValue())
+	}
+	return fmt.Sprintf("[%s%s] %d", m.name, m.labels, m.intValue())
 }
 
 // GetName returns the name of the metric
@@ -54,27 +271,149 @@ func (m *Metric) GetName() string {
 	return m.name
 }
 
-// GetValue returns the value of the metric
+// GetValue returns the value of the metric, float metrics are truncated to an int
 func (m *Metric) GetValue() int {
-	return m.metric
+	if m.float {
+		return int(m.floatValue())
+	}
+	return m.intValue()
+}
+
+// GetFloat returns the value of the metric as a float64
+func (m *Metric) GetFloat() float64 {
+	if m.float {
+		return m.floatValue()
+	}
+	return float64(m.intValue())
+}
+
+// IsFloat returns true if the metric tracks a float64 value
+func (m *Metric) IsFloat() bool {
+	return m.float
+}
+
+// GetKind returns the kind of the metric
+func (m *Metric) GetKind() Kind {
+	return m.kind
+}
+
+// Snapshot returns a copy of the metric with its current value, the copy is not
+// updated when the original metric changes
+func (m *Metric) Snapshot() *Metric {
+	snapshot := &Metric{
+		metric:   atomic.LoadInt64(&m.metric),
+		fmetric:  atomic.LoadUint64(&m.fmetric),
+		name:     m.name,
+		labels:   m.labels,
+		float:    m.float,
+		kind:     m.kind,
+		snapshot: true,
+	}
+
+	if m.hist != nil {
+		snapshot.hist = &histogram{
+			bounds: m.hist.bounds,
+			counts: make([]uint64, len(m.hist.counts)),
+		}
+		for i := range m.hist.counts {
+			snapshot.hist.counts[i] = atomic.LoadUint64(&m.hist.counts[i])
+		}
+	}
+
+	return snapshot
+}
+
+// IsSnapshot returns true if the metric was created by Snapshot, rather than being a live metric
+func (m *Metric) IsSnapshot() bool {
+	return m.snapshot
+}
+
+// value returns the int or float64 value of the metric
+func (m *Metric) value() interface{} {
+	if m.float {
+		return m.floatValue()
+	}
+	return m.intValue()
 }
 
 // AddMetrics adds metric values together and returns the result
-func AddMetrics(metrics ...Metric) int {
-	var total int
+func AddMetrics(metrics ...*Metric) float64 {
+	var total float64
 	for _, m := range metrics {
-		total += m.metric
+		total += m.GetFloat()
 	}
 	return total
 }
 
+// MergeFunc merges metrics that track the same value into a single metric
+type MergeFunc func(metrics ...*Metric) *Metric
+
+// SumMetrics returns a snapshot with the sum of the metric values, see AddMetrics.
+// The name, labels and kind are taken from the first metric and the result is a float metric if any of the metrics are floats.
+// Histograms are merged with MergeHistograms, if the buckets don't match the last histogram is used
+func SumMetrics(metrics ...*Metric) *Metric {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	first := metrics[0]
+	if first.hist != nil {
+		merged, err := MergeHistograms(first.name, metrics...)
+		if err != nil {
+			return LastMetric(metrics...)
+		}
+		merged.labels = first.labels
+		merged.snapshot = true
+		return merged
+	}
+
+	sum := &Metric{name: first.name, labels: first.labels, kind: first.kind, snapshot: true}
+	for _, m := range metrics {
+		sum.float = sum.float || m.float
+	}
+
+	if sum.float {
+		sum.SetFloat(AddMetrics(metrics...))
+		return sum
+	}
+
+	for _, m := range metrics {
+		sum.Add(m.GetValue())
+	}
+	return sum
+}
+
+// MaxMetrics returns a snapshot of the metric with the largest value, histograms are compared by their sum
+func MaxMetrics(metrics ...*Metric) *Metric {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	largest := metrics[0]
+	for _, m := range metrics[1:] {
+		if m.GetFloat() > largest.GetFloat() {
+			largest = m
+		}
+	}
+	return largest.Snapshot()
+}
+
+// LastMetric returns a snapshot of the last metric
+func LastMetric(metrics ...*Metric) *Metric {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	return metrics[len(metrics)-1].Snapshot()
+}
+
 // FilterMetrics takes a list of metrics and returns only those that satisfy the filter metric
-func FilterMetrics(metrics []Metric, filter func(Metric) bool) []Metric {
+func FilterMetrics(metrics []*Metric, filter func(*Metric) bool) []*Metric {
 	if filter == nil {
 		return metrics
 	}
 
-	var filtered []Metric
+	var filtered []*Metric
 	for _, metric := range metrics {
 		if filter(metric) {
 			filtered = append(filtered, metric)
@@ -84,66 +423,67 @@ func FilterMetrics(metrics []Metric, filter func(Metric) bool) []Metric {
 	return filtered
 }
 
-// FMetric is a metric that
+// FMetric is a metric that tracks a float64 value
+//
+// Deprecated: use NewFloatMetric instead, FMetric is only kept so existing code keeps compiling
 type FMetric struct {
-	name   string
-	metric float64
+	Metric
 }
 
 // NewFMetric creates a new float64 metric
+//
+// Deprecated: use NewFloatMetric instead
 func NewFMetric(name string) *FMetric {
 	return &FMetric{
-		name: name,
+		Metric: *NewFloatMetric(name),
 	}
 }
 
-// Incr adds one to the metric
-func (m *FMetric) Incr() {
-	m.metric++
-}
-
-// Decr subtrcts one from the metric
-func (m *FMetric) Decr() {
-	m.metric--
-}
-
 // Add adds an abitrary value to the underlying metric
 func (m *FMetric) Add(add float64) {
-	m.metric += add
+	m.Metric.AddFloat(add)
 }
 
-// MarshalJSON implements the marshaler interface
-func (m *FMetric) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Name  string  `json:"name"`
-		Value float64 `json:"value"`
-	}{
-		m.name,
-		m.metric,
-	})
+// GetValue returns the value of the metric
+func (m *FMetric) GetValue() float64 {
+	return m.Metric.GetFloat()
 }
 
-// string implements the stringer interface
-func (m *FMetric) String() string {
-	return fmt.Sprintf("[%s] %.4f", m.name, m.metric)
+// UnmarshalJSON implements the unmarshaler interface
+func (m *FMetric) UnmarshalJSON(raw []byte) error {
+	if err := m.Metric.UnmarshalJSON(raw); err != nil {
+		return err
+	}
+
+	if !m.float {
+		value := m.intValue()
+		m.Set(0)
+		m.float = true
+		m.SetFloat(float64(value))
+	}
+	return nil
 }
 
 // AddFMetrics adds metric values together and returns the result
-func AddFMetrics(metrics ...FMetric) float64 {
+//
+// Deprecated: use AddMetrics instead
+func AddFMetrics(metrics ...*FMetric) float64 {
 	var total float64
 	for _, m := range metrics {
-		total += m.metric
+		total += m.GetValue()
 	}
 	return total
 }
 
 // FilterFMetrics takes a list of metrics and returns only those that satisfy the filter metric
-func FilterFMetrics(metrics []FMetric, filter func(FMetric) bool) []FMetric {
+//
+// Deprecated: use FilterMetrics instead
+func FilterFMetrics(metrics []*FMetric, filter func(*FMetric) bool) []*FMetric {
 	if filter == nil {
 		return metrics
 	}
 
-	var filtered []FMetric
+	var filtered []*FMetric
 	for _, metric := range metrics {
 		if filter(metric) {
 			filtered = append(filtered, metric)
@@ -152,13 +492,3 @@ func FilterFMetrics(metrics []FMetric, filter func(FMetric) bool) []FMetric {
 
 	return filtered
 }
-
-// GetName returns the name of the metric
-func (m *FMetric) GetName() string {
-	return m.name
-}
-
-// GetValue returns the value of the metric
-func (m *FMetric) GetValue() float64 {
-	return m.metric
-}
@@ -1,7 +1,9 @@
 package metrics
 
 import (
+	"math"
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -125,7 +127,7 @@ func TestMetric_String(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			m := &Metric{
 				name:   tt.fields.name,
-				metric: tt.fields.metric,
+				metric: int64(tt.fields.metric),
 			}
 			if got := m.String(); got != tt.want {
 				t.Errorf("Metric.String() = %v, want %v", got, tt.want)
@@ -136,31 +138,31 @@ func TestMetric_String(t *testing.T) {
 
 func TestAddMetrics(t *testing.T) {
 	type args struct {
-		metrics []Metric
+		metrics []*Metric
 	}
 	tests := []struct {
 		name string
 		args args
-		want int
+		want float64
 	}{
 		{
 			"single metric",
 			args{
-				metrics: []Metric{{name: "A", metric: 10}},
+				metrics: []*Metric{{name: "A", metric: 10}},
 			},
 			10,
 		},
 		{
 			"two metrics",
 			args{
-				metrics: []Metric{{name: "A", metric: 10}, {name: "B", metric: 5}},
+				metrics: []*Metric{{name: "A", metric: 10}, {name: "B", metric: 5}},
 			},
 			15,
 		},
 		{
 			"three metrics",
 			args{
-				metrics: []Metric{{name: "A", metric: 10}, {name: "B", metric: 5}, {name: "C", metric: 7}},
+				metrics: []*Metric{{name: "A", metric: 10}, {name: "B", metric: 5}, {name: "C", metric: 7}},
 			},
 			22,
 		},
@@ -176,27 +178,27 @@ func TestAddMetrics(t *testing.T) {
 
 func TestFilterMetrics(t *testing.T) {
 	type args struct {
-		metrics []Metric
-		filter  func(Metric) bool
+		metrics []*Metric
+		filter  func(*Metric) bool
 	}
 	tests := []struct {
 		name string
 		args args
-		want []Metric
+		want []*Metric
 	}{
 		{
 			"nil filter function",
 			args{
-				metrics: []Metric{{name: "A"}},
+				metrics: []*Metric{{name: "A"}},
 				filter:  nil,
 			},
-			[]Metric{{name: "A"}},
+			[]*Metric{{name: "A"}},
 		},
 		{
 			"no more metrics",
 			args{
-				metrics: []Metric{{name: "A"}, {name: "B"}},
-				filter: func(Metric) bool {
+				metrics: []*Metric{{name: "A"}, {name: "B"}},
+				filter: func(*Metric) bool {
 					return false
 				},
 			},
@@ -205,18 +207,18 @@ func TestFilterMetrics(t *testing.T) {
 		{
 			"all metrics",
 			args{
-				metrics: []Metric{{name: "A"}, {name: "B"}},
-				filter: func(Metric) bool {
+				metrics: []*Metric{{name: "A"}, {name: "B"}},
+				filter: func(*Metric) bool {
 					return true
 				},
 			},
-			[]Metric{{name: "A"}, {name: "B"}},
+			[]*Metric{{name: "A"}, {name: "B"}},
 		},
 		{
 			"some metrics",
 			args{
-				metrics: []Metric{{name: "A", metric: 5}, {name: "B", metric: -5}, {name: "C"}},
-				filter: func(m Metric) bool {
+				metrics: []*Metric{{name: "A", metric: 5}, {name: "B", metric: -5}, {name: "C"}},
+				filter: func(m *Metric) bool {
 					if m.GetName() == "A" {
 						return true
 					}
@@ -227,7 +229,7 @@ func TestFilterMetrics(t *testing.T) {
 					return false
 				},
 			},
-			[]Metric{{name: "A", metric: 5}, {name: "B", metric: -5}},
+			[]*Metric{{name: "A", metric: 5}, {name: "B", metric: -5}},
 		},
 	}
 	for _, tt := range tests {
@@ -256,15 +258,25 @@ func TestMetric_MarshalJSON(t *testing.T) {
 				name:   "Test Metric",
 				metric: 12.44,
 			},
-			`{"name":"Test Metric","value":12.44}`,
+			`{"name":"Test Metric","value":12.44,"float":true}`,
+			false,
+		},
+		{
+			"whole float",
+			fields{
+				name:   "Test Metric",
+				metric: 12,
+			},
+			`{"name":"Test Metric","value":12,"float":true}`,
 			false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := &FMetric{
-				name:   tt.fields.name,
-				metric: tt.fields.metric,
+			m := &Metric{
+				name:    tt.fields.name,
+				float:   true,
+				fmetric: math.Float64bits(tt.fields.metric),
 			}
 			got, err := m.MarshalJSON()
 			if (err != nil) != tt.wantErr {
@@ -398,10 +410,11 @@ func TestFMetric_String(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := &FMetric{
-				name:   tt.fields.name,
-				metric: tt.fields.metric,
-			}
+			m := &FMetric{Metric{
+				name:    tt.fields.name,
+				float:   true,
+				fmetric: math.Float64bits(tt.fields.metric),
+			}}
 			if got := m.String(); got != tt.want {
 				t.Errorf("Metric.String() = %v, want %v", got, tt.want)
 			}
@@ -411,7 +424,7 @@ func TestFMetric_String(t *testing.T) {
 
 func TestAddFMetrics(t *testing.T) {
 	type args struct {
-		metrics []FMetric
+		metrics []*FMetric
 	}
 	tests := []struct {
 		name string
@@ -421,21 +434,21 @@ func TestAddFMetrics(t *testing.T) {
 		{
 			"single metric",
 			args{
-				metrics: []FMetric{{name: "A", metric: 10.5}},
+				metrics: []*FMetric{{Metric{name: "A", float: true, fmetric: math.Float64bits(10.5)}}},
 			},
 			10.5,
 		},
 		{
 			"two metrics",
 			args{
-				metrics: []FMetric{{name: "A", metric: 10.2}, {name: "B", metric: 5.678}},
+				metrics: []*FMetric{{Metric{name: "A", float: true, fmetric: math.Float64bits(10.2)}}, {Metric{name: "B", float: true, fmetric: math.Float64bits(5.678)}}},
 			},
 			15.878,
 		},
 		{
 			"three metrics",
 			args{
-				metrics: []FMetric{{name: "A", metric: 10.2}, {name: "B", metric: 5.678}, {name: "C", metric: 7.01}},
+				metrics: []*FMetric{{Metric{name: "A", float: true, fmetric: math.Float64bits(10.2)}}, {Metric{name: "B", float: true, fmetric: math.Float64bits(5.678)}}, {Metric{name: "C", float: true, fmetric: math.Float64bits(7.01)}}},
 			},
 			22.888,
 		},
@@ -451,27 +464,27 @@ func TestAddFMetrics(t *testing.T) {
 
 func TestFilterFMetrics(t *testing.T) {
 	type args struct {
-		metrics []FMetric
-		filter  func(FMetric) bool
+		metrics []*FMetric
+		filter  func(*FMetric) bool
 	}
 	tests := []struct {
 		name string
 		args args
-		want []FMetric
+		want []*FMetric
 	}{
 		{
 			"nil filter function",
 			args{
-				metrics: []FMetric{{name: "A"}},
+				metrics: []*FMetric{{Metric{name: "A", float: true}}},
 				filter:  nil,
 			},
-			[]FMetric{{name: "A"}},
+			[]*FMetric{{Metric{name: "A", float: true}}},
 		},
 		{
 			"no more metrics",
 			args{
-				metrics: []FMetric{{name: "A"}, {name: "B"}},
-				filter: func(FMetric) bool {
+				metrics: []*FMetric{{Metric{name: "A", float: true}}, {Metric{name: "B", float: true}}},
+				filter: func(*FMetric) bool {
 					return false
 				},
 			},
@@ -480,18 +493,18 @@ func TestFilterFMetrics(t *testing.T) {
 		{
 			"all metrics",
 			args{
-				metrics: []FMetric{{name: "A"}, {name: "B"}},
-				filter: func(FMetric) bool {
+				metrics: []*FMetric{{Metric{name: "A", float: true}}, {Metric{name: "B", float: true}}},
+				filter: func(*FMetric) bool {
 					return true
 				},
 			},
-			[]FMetric{{name: "A"}, {name: "B"}},
+			[]*FMetric{{Metric{name: "A", float: true}}, {Metric{name: "B", float: true}}},
 		},
 		{
 			"some metrics",
 			args{
-				metrics: []FMetric{{name: "A", metric: 5.5}, {name: "B", metric: -5.5}, {name: "C"}},
-				filter: func(m FMetric) bool {
+				metrics: []*FMetric{{Metric{name: "A", float: true, fmetric: math.Float64bits(5.5)}}, {Metric{name: "B", float: true, fmetric: math.Float64bits(-5.5)}}, {Metric{name: "C", float: true}}},
+				filter: func(m *FMetric) bool {
 					if m.GetName() == "A" {
 						return true
 					}
@@ -502,7 +515,7 @@ func TestFilterFMetrics(t *testing.T) {
 					return false
 				},
 			},
-			[]FMetric{{name: "A", metric: 5.5}, {name: "B", metric: -5.5}},
+			[]*FMetric{{Metric{name: "A", float: true, fmetric: math.Float64bits(5.5)}}, {Metric{name: "B", float: true, fmetric: math.Float64bits(-5.5)}}},
 		},
 	}
 	for _, tt := range tests {
@@ -531,16 +544,17 @@ func TestFMetric_MarshalJSON(t *testing.T) {
 				name:   "Test Metric",
 				metric: 10.5393,
 			},
-			`{"name":"Test Metric","value":10.5393}`,
+			`{"name":"Test Metric","value":10.5393,"float":true}`,
 			false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := &FMetric{
-				name:   tt.fields.name,
-				metric: tt.fields.metric,
-			}
+			m := &FMetric{Metric{
+				name:    tt.fields.name,
+				float:   true,
+				fmetric: math.Float64bits(tt.fields.metric),
+			}}
 			got, err := m.MarshalJSON()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Metric.MarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
@@ -552,3 +566,262 @@ func TestFMetric_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestMetric_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    *Metric
+		wantErr bool
+	}{
+		{
+			"valid json",
+			`{"name":"Test Metric","value":12}`,
+			&Metric{name: "Test Metric", metric: 12},
+			false,
+		},
+		{
+			"float value",
+			`{"name":"Test Metric","value":12.5}`,
+			&Metric{name: "Test Metric", float: true, fmetric: math.Float64bits(12.5)},
+			false,
+		},
+		{
+			"whole float value",
+			`{"name":"Test Metric","value":12,"float":true}`,
+			&Metric{name: "Test Metric", float: true, fmetric: math.Float64bits(12)},
+			false,
+		},
+		{
+			"labeled metric",
+			`{"name":"Test Metric","labels":{"table":"users"},"value":12}`,
+			&Metric{name: "Test Metric", labels: Labels{"table": "users"}, metric: 12},
+			false,
+		},
+		{
+			"string value",
+			`{"name":"Test Metric","value":"12"}`,
+			&Metric{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Metric{}
+			err := m.UnmarshalJSON([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Metric.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Metric.UnmarshalJSON() = %v, want %v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestFMetric_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    *FMetric
+		wantErr bool
+	}{
+		{
+			"valid json",
+			`{"name":"Test Metric","value":10.5393}`,
+			&FMetric{Metric{name: "Test Metric", float: true, fmetric: math.Float64bits(10.5393)}},
+			false,
+		},
+		{
+			"int value",
+			`{"name":"Test Metric","value":10}`,
+			&FMetric{Metric{name: "Test Metric", float: true, fmetric: math.Float64bits(10)}},
+			false,
+		},
+		{
+			"string value",
+			`{"name":"Test Metric","value":"10"}`,
+			&FMetric{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &FMetric{}
+			err := m.UnmarshalJSON([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FMetric.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("FMetric.UnmarshalJSON() = %v, want %v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetric_Set(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric *Metric
+		set    func(m *Metric)
+		want   string
+	}{
+		{
+			"int gauge",
+			NewGauge("Test Gauge"),
+			func(m *Metric) { m.Set(10) },
+			"[Test Gauge] 10",
+		},
+		{
+			"float gauge",
+			NewFloatGauge("Test Gauge"),
+			func(m *Metric) { m.SetFloat(1.25) },
+			"[Test Gauge] 1.2500",
+		},
+		{
+			"float value on int gauge",
+			NewGauge("Test Gauge"),
+			func(m *Metric) { m.SetFloat(1.75) },
+			"[Test Gauge] 1",
+		},
+		{
+			"reset counter",
+			NewMetric("Test Metric"),
+			func(m *Metric) {
+				m.Add(5)
+				m.Set(0)
+			},
+			"[Test Metric] 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.set(tt.metric)
+			if got := tt.metric.String(); got != tt.want {
+				t.Errorf("Metric.Set() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if NewGauge("gauge").GetKind() != KindGauge || NewMetric("counter").GetKind() != KindCounter {
+		t.Errorf("Metric.GetKind() returned the wrong kind")
+	}
+}
+
+func TestMetric_Concurrent(t *testing.T) {
+	counter := NewMetric("counter")
+	fcounter := NewFloatMetric("fcounter")
+	gauge := NewFloatGauge("gauge")
+
+	workers := 50
+	iterations := 1000
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				counter.Incr()
+				fcounter.AddFloat(0.5)
+				gauge.SetFloat(float64(i))
+
+				// reads should be safe while the metrics are being updated
+				_ = counter.String()
+				_, _ = fcounter.MarshalJSON()
+				_ = gauge.GetFloat()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := counter.GetValue(); got != workers*iterations {
+		t.Errorf("Metric.Incr() concurrent total = %d, want %d", got, workers*iterations)
+	}
+	if got := fcounter.GetFloat(); got != float64(workers*iterations)/2 {
+		t.Errorf("Metric.AddFloat() concurrent total = %f, want %f", got, float64(workers*iterations)/2)
+	}
+	if got := gauge.GetFloat(); got < 0 || got >= float64(workers) {
+		t.Errorf("Metric.SetFloat() concurrent value = %f, want a value set by a worker", got)
+	}
+}
+
+func TestMetric_Snapshot(t *testing.T) {
+	m := NewFloatMetric("load").WithLabels(Labels{"host": "a"})
+	m.SetFloat(0.5)
+	snapshot := m.Snapshot()
+	m.SetFloat(0.75)
+
+	if !snapshot.IsSnapshot() || m.IsSnapshot() {
+		t.Errorf("Metric.Snapshot() IsSnapshot() = %v, live IsSnapshot() = %v", snapshot.IsSnapshot(), m.IsSnapshot())
+	}
+	if got := snapshot.String(); got != `[load{host="a"}] 0.5000` {
+		t.Errorf("Metric.Snapshot() = %v", got)
+	}
+
+	h := NewHistogram("latency", 1)
+	h.Observe(0.5)
+	hsnap := h.Snapshot()
+	h.Observe(2)
+	if hsnap.GetCount() != 1 || hsnap.GetFloat() != 0.5 {
+		t.Errorf("Metric.Snapshot() histogram = %v", hsnap)
+	}
+
+	raw, err := snapshot.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Metric.MarshalJSON() error = %v", err)
+	}
+	parsed := &Metric{}
+	if err := parsed.UnmarshalJSON(raw); err != nil {
+		t.Fatalf("Metric.UnmarshalJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed, snapshot) {
+		t.Errorf("Metric.UnmarshalJSON() = %v, want %v", parsed, snapshot)
+	}
+}
+
+func TestMergeFuncs(t *testing.T) {
+	a := NewMetric("retries")
+	a.Add(3)
+	b := NewMetric("retries")
+	b.Add(5)
+	c := NewFloatMetric("retries")
+	c.AddFloat(1.5)
+	h1 := NewHistogram("latency", 1)
+	h1.Observe(0.5)
+	h2 := NewHistogram("latency", 1)
+	h2.Observe(2)
+
+	tests := []struct {
+		name  string
+		merge MergeFunc
+		args  []*Metric
+		want  string
+	}{
+		{"sum no metrics", SumMetrics, nil, "<nil>"},
+		{"sum ints", SumMetrics, []*Metric{a, b}, "[retries] 8"},
+		{"sum mixed", SumMetrics, []*Metric{a, c}, "[retries] 4.5000"},
+		{"sum histograms", SumMetrics, []*Metric{h1, h2}, "[latency] count=2 sum=2.5000"},
+		{"sum mismatched histograms", SumMetrics, []*Metric{h1, NewHistogram("latency", 2)}, "[latency] count=0 sum=0.0000"},
+		{"max", MaxMetrics, []*Metric{a, b, c}, "[retries] 5"},
+		{"max histograms", MaxMetrics, []*Metric{h2, h1}, "[latency] count=1 sum=2.0000"},
+		{"last", LastMetric, []*Metric{b, c, a}, "[retries] 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.merge(tt.args...)
+			if got == nil {
+				if tt.want != "<nil>" {
+					t.Errorf("merge() = nil, want %v", tt.want)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("merge() = %v, want %v", got, tt.want)
+			}
+			if !got.IsSnapshot() {
+				t.Errorf("merge() did not return a snapshot")
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes all the metrics in the registry using the prometheus text exposition format
+// https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, f := range r.snapshot() {
+		if len(f.metrics) == 0 {
+			continue
+		}
+
+		name := sanitizeName(f.name)
+		if f.help != "" {
+			buf.WriteString("# HELP " + name + " " + escapeHelp(f.help) + "\n")
+		}
+		buf.WriteString("# TYPE " + name + " " + kindName(f.kind) + "\n")
+
+		for _, reg := range f.sorted() {
+			writeSamples(buf, name, reg)
+		}
+	}
+
+	return buf.Flush()
+}
+
+// Handler returns an http.Handler that serves the registry in the prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", PrometheusContentType)
+		_ = r.WritePrometheus(w)
+	})
+}
+
+// writeSamples writes the samples for a single metric
+func writeSamples(buf *bufio.Writer, name string, reg *registered) {
+	m := reg.metric
+	if m.kind != KindHistogram {
+		buf.WriteString(name + sanitizeLabels(reg.labels).String() + " " + formatValue(m) + "\n")
+		return
+	}
+
+	for _, bucket := range m.GetBuckets() {
+		labels := sanitizeLabels(reg.labels)
+		labels["le"] = formatFloat(bucket.UpperBound)
+		buf.WriteString(name + "_bucket" + labels.String() + " " + strconv.FormatUint(bucket.Count, 10) + "\n")
+	}
+
+	labels := sanitizeLabels(reg.labels)
+	labels["le"] = "+Inf"
+	count := strconv.FormatUint(m.GetCount(), 10)
+	buf.WriteString(name + "_bucket" + labels.String() + " " + count + "\n")
+
+	labels = sanitizeLabels(reg.labels)
+	buf.WriteString(name + "_sum" + labels.String() + " " + formatFloat(m.GetFloat()) + "\n")
+	buf.WriteString(name + "_count" + labels.String() + " " + count + "\n")
+}
+
+// kindName returns the prometheus type name for the kind
+func kindName(kind Kind) string {
+	switch kind {
+	case KindCounter:
+		return "counter"
+	case KindGauge:
+		return "gauge"
+	case KindHistogram:
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+// formatValue formats the metric value for prometheus
+func formatValue(m *Metric) string {
+	if m.IsFloat() {
+		return formatFloat(m.GetFloat())
+	}
+	return strconv.Itoa(m.GetValue())
+}
+
+// formatFloat formats a float for prometheus
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+// sanitizeName replaces any characters that are not valid in a prometheus metric name with _
+func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// sanitizeLabels returns a copy of the labels with any invalid label names sanitized
+func sanitizeLabels(labels Labels) Labels {
+	sanitized := make(Labels, len(labels))
+	for key, value := range labels {
+		sanitized[sanitize(key, false)] = value
+	}
+	return sanitized
+}
+
+// sanitize replaces any invalid characters with _, colons are only valid in metric names
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' ||
+			(c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0) ||
+			(c == ':' && allowColon)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// quoteLabel quotes and escapes a label value
+func quoteLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// escapeHelp escapes help text
+func escapeHelp(help string) string {
+	help = strings.ReplaceAll(help, `\`, `\\`)
+	return strings.ReplaceAll(help, "\n", `\n`)
+}
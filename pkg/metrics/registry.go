@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry owns a set of named metrics, each metric is identified by its name and labels
+// all the methods on Registry are safe to use from multiple goroutines
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// family is all the metrics in a registry that share a name
+type family struct {
+	name    string
+	help    string
+	kind    Kind
+	metrics map[string]*registered
+}
+
+// registered is a metric and the labels it was registered with
+type registered struct {
+	labels Labels
+	metric *Metric
+}
+
+// DefaultRegistry is a registry that can be shared across packages
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// Counter returns the int counter with the name and labels, creating it if it doesn't exist
+func (r *Registry) Counter(name string, labels Labels) *Metric {
+	return r.getOrCreate(name, labels, KindCounter, func() *Metric { return NewMetric(name) })
+}
+
+// FloatCounter returns the float64 counter with the name and labels, creating it if it doesn't exist
+func (r *Registry) FloatCounter(name string, labels Labels) *Metric {
+	return r.getOrCreate(name, labels, KindCounter, func() *Metric { return NewFloatMetric(name) })
+}
+
+// Gauge returns the int gauge with the name and labels, creating it if it doesn't exist
+func (r *Registry) Gauge(name string, labels Labels) *Metric {
+	return r.getOrCreate(name, labels, KindGauge, func() *Metric { return NewGauge(name) })
+}
+
+// FloatGauge returns the float64 gauge with the name and labels, creating it if it doesn't exist
+func (r *Registry) FloatGauge(name string, labels Labels) *Metric {
+	return r.getOrCreate(name, labels, KindGauge, func() *Metric { return NewFloatGauge(name) })
+}
+
+// Histogram returns the histogram with the name and labels, creating it if it doesn't exist
+// the buckets are only used if the histogram is created
+func (r *Registry) Histogram(name string, labels Labels, buckets ...float64) *Metric {
+	return r.getOrCreate(name, labels, KindHistogram, func() *Metric { return NewHistogram(name, buckets...) })
+}
+
+// Register adds an existing metric to the registry under its name and the labels,
+// if no labels are given the metric's own labels are used.
+// An error is returned if a different metric is already registered with the same name and labels
+// or if metrics of a different kind are registered under the same name
+func (r *Registry) Register(m *Metric, labels Labels) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := r.family(m.name, m.kind)
+	if err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		labels = m.labels
+	}
+	key := labels.String()
+	if existing, ok := f.metrics[key]; ok && existing.metric != m {
+		return fmt.Errorf("metric %s%s is already registered", m.name, key)
+	}
+
+	f.metrics[key] = &registered{labels: copyLabels(labels), metric: m}
+	return nil
+}
+
+// SetHelp sets the help text for all the metrics with the name
+func (r *Registry) SetHelp(name, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		f.help = help
+		return
+	}
+
+	// the kind is set once the first metric is registered
+	r.families[name] = &family{name: name, help: help, kind: -1, metrics: make(map[string]*registered)}
+}
+
+// Each calls fn with every metric in the registry, sorted by name and labels
+func (r *Registry) Each(fn func(m *Metric, labels Labels)) {
+	for _, f := range r.snapshot() {
+		for _, reg := range f.sorted() {
+			fn(reg.metric, copyLabels(reg.labels))
+		}
+	}
+}
+
+// getOrCreate returns the metric with the name and labels, creating it if it doesn't exist.
+// It panics if metrics of a different kind are already registered under the same name
+func (r *Registry) getOrCreate(name string, labels Labels, kind Kind, create func() *Metric) *Metric {
+	key := labels.String()
+
+	r.mu.RLock()
+	if f, ok := r.families[name]; ok && f.kind == kind {
+		if reg, ok := f.metrics[key]; ok {
+			r.mu.RUnlock()
+			return reg.metric
+		}
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := r.family(name, kind)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// another goroutine may have created the metric while the lock was released
+	if reg, ok := f.metrics[key]; ok {
+		return reg.metric
+	}
+
+	m := create()
+	m.labels = copyLabels(labels)
+	f.metrics[key] = &registered{labels: copyLabels(labels), metric: m}
+	return m
+}
+
+// family returns the family with the name, creating it if it doesn't exist. r.mu must be held
+func (r *Registry) family(name string, kind Kind) (*family, error) {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, kind: kind, metrics: make(map[string]*registered)}
+		r.families[name] = f
+	}
+
+	if f.kind == -1 {
+		f.kind = kind
+	}
+	if f.kind != kind {
+		return nil, fmt.Errorf("metric %s is already registered with a different kind", name)
+	}
+
+	return f, nil
+}
+
+// snapshot returns a copy of the families sorted by name
+func (r *Registry) snapshot() []*family {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		copied := &family{name: f.name, help: f.help, kind: f.kind, metrics: make(map[string]*registered, len(f.metrics))}
+		for key, reg := range f.metrics {
+			copied.metrics[key] = reg
+		}
+		families = append(families, copied)
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	return families
+}
+
+// sorted returns the metrics in the family sorted by their labels
+func (f *family) sorted() []*registered {
+	keys := make([]string, 0, len(f.metrics))
+	for key := range f.metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*registered, len(keys))
+	for i, key := range keys {
+		sorted[i] = f.metrics[key]
+	}
+	return sorted
+}
+
+// Sample is a snapshot of a metric's value at a point in time
+type Sample struct {
+	Name   string
+	Labels Labels
+	Kind   Kind
+	// Value is the value of the metric, for histograms it's the sum of the observed values
+	Value float64
+	// Count is the number of observed values, it's only set for histograms
+	Count uint64
+}
+
+// Snapshot returns the current value of every metric in the registry, sorted by name and labels
+func (r *Registry) Snapshot() []Sample {
+	var samples []Sample
+	r.Each(func(m *Metric, labels Labels) {
+		samples = append(samples, Sample{
+			Name:   m.GetName(),
+			Labels: labels,
+			Kind:   m.GetKind(),
+			Value:  m.GetFloat(),
+			Count:  m.GetCount(),
+		})
+	})
+
+	return samples
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares got with the golden file, if the update flag is set the golden file is updated instead
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file %s, %v", path, err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s, %v", path, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("output did not match %s got \n%s\nwant \n%s", path, got, want)
+	}
+}
+
+func TestRegistry_GetOrCreate(t *testing.T) {
+	r := NewRegistry()
+
+	a := r.Counter("errors", Labels{"table": "users"})
+	b := r.Counter("errors", Labels{"table": "users"})
+	c := r.Counter("errors", Labels{"table": "orders"})
+	if a != b {
+		t.Errorf("Registry.Counter() created a new metric for the same name and labels")
+	}
+	if a == c {
+		t.Errorf("Registry.Counter() returned the same metric for different labels")
+	}
+
+	if got := a.GetLabels(); !reflect.DeepEqual(got, Labels{"table": "users"}) {
+		t.Errorf("Registry.Counter() metric labels = %v, want %v", got, Labels{"table": "users"})
+	}
+
+	labels := Labels{"a": "1", "b": "2"}
+	d := r.Gauge("gauge", labels)
+	labels["a"] = "changed"
+	if e := r.Gauge("gauge", Labels{"b": "2", "a": "1"}); d != e {
+		t.Errorf("Registry.Gauge() labels were not copied or were order dependent")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registry.Gauge() did not panic when the kind did not match")
+		}
+	}()
+	r.Gauge("errors", nil)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	m := NewMetric("requests")
+
+	if err := r.Register(m, Labels{"code": "200"}); err != nil {
+		t.Fatalf("Registry.Register() error = %v", err)
+	}
+	if err := r.Register(m, Labels{"code": "200"}); err != nil {
+		t.Errorf("Registry.Register() error registering the same metric twice = %v", err)
+	}
+	if got := r.Counter("requests", Labels{"code": "200"}); got != m {
+		t.Errorf("Registry.Counter() did not return the registered metric")
+	}
+	if err := r.Register(NewMetric("requests"), Labels{"code": "200"}); err == nil {
+		t.Errorf("Registry.Register() registered a different metric with the same name and labels")
+	}
+	if err := r.Register(NewGauge("requests"), nil); err == nil {
+		t.Errorf("Registry.Register() registered a metric with a different kind")
+	}
+}
+
+func TestRegistry_Each(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("b", Labels{"x": "2"})
+	r.Counter("b", Labels{"x": "1"})
+	r.Gauge("a", nil)
+
+	var got []string
+	r.Each(func(m *Metric, labels Labels) {
+		got = append(got, m.GetName()+labels.String())
+	})
+
+	want := []string{"a", `b{x="1"}`, `b{x="2"}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.Each() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests", Labels{"code": "500"}).Add(3)
+	r.FloatGauge("load", nil).SetFloat(0.5)
+	h := r.Histogram("latency", nil, 1, 2)
+	h.AddFloat(0.5)
+	h.AddFloat(1.5)
+
+	want := []Sample{
+		{Name: "latency", Kind: KindHistogram, Value: 2, Count: 2},
+		{Name: "load", Kind: KindGauge, Value: 0.5},
+		{Name: "requests", Labels: Labels{"code": "500"}, Kind: KindCounter, Value: 3},
+	}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.Snapshot() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.SetHelp("bear_errors_total", "Total number of errors.\nSplit by table.")
+	r.Counter("bear_errors_total", Labels{"table": "users"}).Add(3)
+	r.Counter("bear_errors_total", Labels{"table": "orders"}).Incr()
+	r.FloatGauge("temperature", Labels{"room": `say "hi"\n`}).SetFloat(21.5)
+	r.Gauge("queue depth", nil).Set(-2)
+
+	h := r.Histogram("db.latency", Labels{"op": "select"}, 0.1, 1)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	// help without any metrics should not be written
+	r.SetHelp("unused", "not used")
+
+	var got bytes.Buffer
+	if err := r.WritePrometheus(&got); err != nil {
+		t.Fatalf("Registry.WritePrometheus() error = %v", err)
+	}
+
+	checkGolden(t, "registry", got.Bytes())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests", nil).Incr()
+
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != PrometheusContentType {
+		t.Errorf("Registry.Handler() content type = %s, want %s", got, PrometheusContentType)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("io.ReadAll() error = %v", err)
+	}
+	checkGolden(t, "handler", body)
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Counter("errors", Labels{"kind": "db"}).Incr()
+				_ = r.WritePrometheus(io.Discard)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := r.Counter("errors", Labels{"kind": "db"}).GetValue(); got != 2000 {
+		t.Errorf("Registry.Counter() concurrent total = %d, want 2000", got)
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer tracks how long an operation takes
+type Timer struct {
+	name       string
+	start      time.Time
+	histograms []*Metric
+
+	once    sync.Once
+	elapsed *Metric
+}
+
+// StartTimer starts a new timer, when the timer is stopped the elapsed time in seconds
+// is observed by each of the histograms
+func StartTimer(name string, histograms ...*Metric) *Timer {
+	return &Timer{
+		name:       name,
+		start:      time.Now(),
+		histograms: histograms,
+	}
+}
+
+// Stop stops the timer and returns a float gauge with the elapsed time in seconds
+// calling stop more than once returns the same metric and does not update the histograms again
+func (t *Timer) Stop() *Metric {
+	t.once.Do(func() {
+		seconds := time.Since(t.start).Seconds()
+
+		t.elapsed = NewFloatGauge(t.name)
+		t.elapsed.SetFloat(seconds)
+		for _, h := range t.histograms {
+			h.Observe(seconds)
+		}
+	})
+
+	return t.elapsed
+}
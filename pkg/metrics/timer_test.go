@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimer_Stop(t *testing.T) {
+	h := NewHistogram("db", 0.001, 10)
+	timer := StartTimer("db", h)
+	time.Sleep(2 * time.Millisecond)
+
+	elapsed := timer.Stop()
+	if elapsed.GetName() != "db" {
+		t.Errorf("Timer.Stop() name = %s, want db", elapsed.GetName())
+	}
+	if elapsed.GetKind() != KindGauge || !elapsed.IsFloat() {
+		t.Errorf("Timer.Stop() did not return a float gauge")
+	}
+	if elapsed.GetFloat() < 0.002 {
+		t.Errorf("Timer.Stop() elapsed = %f, want at least 0.002", elapsed.GetFloat())
+	}
+
+	if h.GetCount() != 1 || h.GetBuckets()[1].Count != 1 {
+		t.Errorf("Timer.Stop() did not observe the elapsed time, got %v", h.GetBuckets())
+	}
+
+	// stopping again should not change anything
+	if again := timer.Stop(); again != elapsed || h.GetCount() != 1 {
+		t.Errorf("Timer.Stop() second stop changed the timer")
+	}
+}
@@ -0,0 +1,275 @@
+package bear
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// bearPkg and bearSubPkgs are the function prefixes for frames inside the bear packages
+const (
+	bearPkg     = "github.com/bjatkin/bear."
+	bearSubPkgs = "github.com/bjatkin/bear/pkg/"
+)
+
+// maxStackDepth is the maximum number of program counters captured for a stack
+const maxStackDepth = 64
+
+// stackFrame is a stack frame in the codes execution
+type stackFrame struct {
+	filename string
+	line     int
+	function string
+	pkg      string
+}
+
+// Frame is a single frame in an error's stack trace
+type Frame struct {
+	// File is the full path of the file
+	File string
+	// Line is the line number in the file
+	Line int
+	// Function is the fully qualified function name e.g. github.com/bjatkin/bear.New
+	Function string
+	// Package is the package path of the function e.g. github.com/bjatkin/bear
+	Package string
+}
+
+func (f stackFrame) String() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		panic("failed to get current working dir: " + err.Error())
+	}
+	return fmt.Sprintf("%s:%d", strings.TrimPrefix(f.filename, dir+"/"), f.line)
+}
+
+// StackDepth is the maximum number of frames captured for an error's stack trace
+// any positive value can be used to capture at most that many frames after filtered frames are removed,
+// negative values are the same as StackFull
+type StackDepth int
+
+const (
+	// StackFull captures every frame in the stack
+	StackFull StackDepth = -1
+	// StackNone does not capture a stack trace at all
+	StackNone StackDepth = 0
+	// StackCaller captures only the frame that created the error
+	StackCaller StackDepth = 1
+)
+
+// defaultStackDepth is the stack depth used by errors that don't set WithStackDepth
+var defaultStackDepth = int64(StackFull)
+
+// SetDefaultStackDepth sets the stack depth used by all errors that don't set WithStackDepth
+func SetDefaultStackDepth(depth StackDepth) {
+	atomic.StoreInt64(&defaultStackDepth, int64(depth))
+}
+
+// DefaultStackDepth returns the stack depth used by all errors that don't set WithStackDepth
+func DefaultStackDepth() StackDepth {
+	return StackDepth(atomic.LoadInt64(&defaultStackDepth))
+}
+
+// WithStackDepth sets the number of frames captured for the error's stack trace
+func WithStackDepth(depth StackDepth) ErrOption {
+	return func(e *Error) {
+		e.stackDepth = depth
+	}
+}
+
+// stack is the call stack that lead up to an error being created
+// only the program counters are captured when the error is created,
+// the frames are symbolized the first time they're needed
+type stack struct {
+	pcs    []uintptr
+	limit  StackDepth
+	once   sync.Once
+	frames []stackFrame
+}
+
+// getStackTrace captures the program counters that lead up to an error being created
+// initialSkip is the number of callers to skip, 1 being the caller of getStackTrace
+func getStackTrace(initialSkip int, depth StackDepth) *stack {
+	if depth == StackNone {
+		return nil
+	}
+	if depth < 0 {
+		depth = StackFull
+	}
+
+	// frames are filtered when they're symbolized, so more than depth frames are captured
+	// to make sure there are still depth frames left once the filtered frames are removed
+	size := int(depth)
+	if depth == StackFull || size < maxStackDepth {
+		size = maxStackDepth
+	}
+
+	// capture into a buffer on the stack first so only the frames that were found are allocated
+	var buf [maxStackDepth]uintptr
+	for {
+		var pcs []uintptr
+		if size <= maxStackDepth {
+			pcs = buf[:size]
+		} else {
+			pcs = make([]uintptr, size)
+		}
+
+		n := runtime.Callers(initialSkip+1, pcs)
+		if n < size || depth != StackFull {
+			s := &stack{pcs: make([]uintptr, n), limit: depth}
+			copy(s.pcs, pcs)
+			return s
+		}
+
+		// the stack was larger than the buffer so try again with more space
+		size *= 2
+	}
+}
+
+// getPanicStack captures the program counters of a panicking goroutine
+// the full stack is captured so that the runtime's panic frames can be filtered
+// out before the depth limit is applied
+func getPanicStack(initialSkip int, depth StackDepth) *stack {
+	if depth == StackNone {
+		return nil
+	}
+
+	s := getStackTrace(initialSkip+1, StackFull)
+	s.limit = depth
+
+	return s
+}
+
+// Frames returns the symbolized stack frames with any filtered frames removed
+func (s *stack) Frames() []stackFrame {
+	if s == nil {
+		return nil
+	}
+
+	s.once.Do(func() {
+		if s.frames == nil {
+			s.frames = symbolize(s.pcs, s.limit)
+		}
+		if s.limit > 0 && len(s.frames) > int(s.limit) {
+			s.frames = s.frames[:s.limit]
+		}
+	})
+
+	return s.frames
+}
+
+// symbolize converts program counters into stack frames, filtering out any excluded frames.
+// Once limit frames have been kept the rest are skipped, a limit of StackFull keeps every frame
+func symbolize(pcs []uintptr, limit StackDepth) []stackFrame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var frames []stackFrame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callers.Next()
+		if keepFrame(frame.Function, frame.File) {
+			frames = append(frames, stackFrame{
+				filename: frame.File,
+				line:     frame.Line,
+				function: frame.Function,
+				pkg:      funcPackage(frame.Function),
+			})
+		}
+
+		if !more || (limit > 0 && len(frames) >= int(limit)) {
+			break
+		}
+	}
+
+	return frames
+}
+
+// funcPackage returns the package path of a fully qualified function name
+// e.g. github.com/bjatkin/bear.(*Error).Error returns github.com/bjatkin/bear
+// the runtime escapes dots in the last path element as %2e so they're unescaped here
+func funcPackage(function string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	if lastSlash < 0 {
+		lastSlash = 0
+	}
+
+	dot := strings.Index(function[lastSlash:], ".")
+	if dot < 0 {
+		return function
+	}
+
+	return strings.ReplaceAll(function[:lastSlash+dot], "%2e", ".")
+}
+
+// frame filters are prefixes matched against the fully qualified function name of a frame
+var (
+	defaultExcludeFrames = []string{"runtime.", "testing."}
+	includeFrames        []string
+	excludeFrames        []string
+	frameFiltersMu       sync.RWMutex
+)
+
+// IncludeFrames adds function prefixes (e.g. runtime.gopanic) that will always be kept in stack traces
+// include rules take priority over any exclude rules
+func IncludeFrames(prefixes ...string) {
+	frameFiltersMu.Lock()
+	defer frameFiltersMu.Unlock()
+
+	includeFrames = append(includeFrames, prefixes...)
+}
+
+// ExcludeFrames adds function prefixes (e.g. net/http.) that will be removed from stack traces
+// runtime, testing and bear internal frames, including the bear/pkg packages, are always excluded unless they're explicitly included
+func ExcludeFrames(prefixes ...string) {
+	frameFiltersMu.Lock()
+	defer frameFiltersMu.Unlock()
+
+	excludeFrames = append(excludeFrames, prefixes...)
+}
+
+// ResetFrameFilters removes any include or exclude rules added with IncludeFrames or ExcludeFrames
+func ResetFrameFilters() {
+	frameFiltersMu.Lock()
+	defer frameFiltersMu.Unlock()
+
+	includeFrames = nil
+	excludeFrames = nil
+}
+
+// keepFrame returns true if the frame should be kept in the stack trace
+func keepFrame(function, file string) bool {
+	frameFiltersMu.RLock()
+	defer frameFiltersMu.RUnlock()
+
+	if hasAnyPrefix(function, includeFrames) {
+		return true
+	}
+
+	if hasAnyPrefix(function, defaultExcludeFrames) || hasAnyPrefix(function, excludeFrames) {
+		return false
+	}
+
+	// frames from inside the bear packages are internal, tests in the packages are still kept
+	internal := strings.HasPrefix(function, bearPkg) || strings.HasPrefix(function, bearSubPkgs)
+	if internal && !strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+
+	return true
+}
+
+// hasAnyPrefix returns true if s starts with any of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,322 @@
+package bear
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetStackTrace(t *testing.T) {
+	frames := New().stack.Frames()
+	if len(frames) == 0 {
+		t.Fatalf("getStackTrace() got no frames")
+	}
+
+	first := frames[0]
+	if first.function != "github.com/bjatkin/bear.TestGetStackTrace" {
+		t.Errorf("getStackTrace() first frame function = %s, want TestGetStackTrace", first.function)
+	}
+	if first.pkg != "github.com/bjatkin/bear" {
+		t.Errorf("getStackTrace() first frame package = %s, want github.com/bjatkin/bear", first.pkg)
+	}
+	if !strings.HasSuffix(first.filename, "stack_test.go") {
+		t.Errorf("getStackTrace() first frame file = %s, want stack_test.go", first.filename)
+	}
+
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.function, "runtime.") || strings.HasPrefix(frame.function, "testing.") {
+			t.Errorf("getStackTrace() included filtered frame %s", frame.function)
+		}
+	}
+}
+
+func TestError_GetFrames(t *testing.T) {
+	e := New()
+	frames := e.GetFrames()
+	if len(frames) == 0 || len(frames) != len(e.GetStack()) {
+		t.Fatalf("GetFrames() got %d frames, want %d", len(frames), len(e.GetStack()))
+	}
+
+	first := frames[0]
+	if first.Function != "github.com/bjatkin/bear.TestError_GetFrames" || first.Package != "github.com/bjatkin/bear" {
+		t.Errorf("GetFrames() first frame = %s in %s, want TestError_GetFrames in github.com/bjatkin/bear", first.Function, first.Package)
+	}
+	if !strings.HasSuffix(first.File, "stack_test.go") || first.Line == 0 {
+		t.Errorf("GetFrames() first frame = %s:%d, want stack_test.go", first.File, first.Line)
+	}
+
+	if frames := New(WithStackDepth(StackNone)).GetFrames(); frames != nil {
+		t.Errorf("GetFrames() = %v, want no frames with StackNone", frames)
+	}
+}
+
+func TestFrameFilters(t *testing.T) {
+	defer ResetFrameFilters()
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		function string
+		file     string
+		want     bool
+	}{
+		{
+			"user frame",
+			nil,
+			nil,
+			"main.main",
+			"main.go",
+			true,
+		},
+		{
+			"runtime frame",
+			nil,
+			nil,
+			"runtime.goexit",
+			"asm_amd64.s",
+			false,
+		},
+		{
+			"testing frame",
+			nil,
+			nil,
+			"testing.tRunner",
+			"testing.go",
+			false,
+		},
+		{
+			"bear internal frame",
+			nil,
+			nil,
+			"github.com/bjatkin/bear.Wrap",
+			"error.go",
+			false,
+		},
+		{
+			"bear test frame",
+			nil,
+			nil,
+			"github.com/bjatkin/bear.TestNew",
+			"error_test.go",
+			true,
+		},
+		{
+			"bear sub package frame",
+			nil,
+			nil,
+			"github.com/bjatkin/bear/pkg/metrics.NewMetric",
+			"metrics.go",
+			false,
+		},
+		{
+			"bear sub package test frame",
+			nil,
+			nil,
+			"github.com/bjatkin/bear/pkg/http.TestMiddleware",
+			"middleware_test.go",
+			true,
+		},
+		{
+			"excluded frame",
+			nil,
+			[]string{"net/http."},
+			"net/http.HandlerFunc.ServeHTTP",
+			"server.go",
+			false,
+		},
+		{
+			"included frame",
+			[]string{"runtime.gopanic"},
+			nil,
+			"runtime.gopanic",
+			"panic.go",
+			true,
+		},
+		{
+			"include beats exclude",
+			[]string{"main.handle"},
+			[]string{"main."},
+			"main.handle",
+			"main.go",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetFrameFilters()
+			IncludeFrames(tt.include...)
+			ExcludeFrames(tt.exclude...)
+
+			if got := keepFrame(tt.function, tt.file); got != tt.want {
+				t.Errorf("keepFrame() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		want     string
+	}{
+		{"main", "main.main", "main"},
+		{"std lib", "net/http.(*conn).serve", "net/http"},
+		{"method", "github.com/bjatkin/bear.(*Error).Error", "github.com/bjatkin/bear"},
+		{"closure", "github.com/bjatkin/bear.TestNew.func1", "github.com/bjatkin/bear"},
+		{"dotted path", "gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := funcPackage(tt.function); got != tt.want {
+				t.Errorf("funcPackage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithStackDepth(t *testing.T) {
+	tmpl := NewTemplate(WithStackDepth(StackCaller))
+
+	tests := []struct {
+		name      string
+		err       func() *Error
+		wantLen   int
+		wantFirst string
+	}{
+		{
+			"no stack",
+			func() *Error { return New(WithStackDepth(StackNone)) },
+			0,
+			"",
+		},
+		{
+			"caller only",
+			func() *Error { return New(WithStackDepth(StackCaller)) },
+			1,
+			"github.com/bjatkin/bear.TestWithStackDepth.func2",
+		},
+		{
+			"bounded depth",
+			func() *Error { return Wrap(io.EOF, WithStackDepth(2)) },
+			2,
+			"github.com/bjatkin/bear.TestWithStackDepth.func3",
+		},
+		{
+			"template",
+			func() *Error { return tmpl.New() },
+			1,
+			"github.com/bjatkin/bear.TestWithStackDepth.func4",
+		},
+		{
+			"wrapped panic",
+			func() *Error {
+				e := func() (e *Error) {
+					e = New()
+					defer e.WrapPanic(WithStackDepth(StackCaller))
+					panic("panicking")
+				}()
+				return e.parents[0].(*Error)
+			},
+			1,
+			"github.com/bjatkin/bear.TestWithStackDepth.func5.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := tt.err().stack.Frames()
+			if len(frames) != tt.wantLen {
+				t.Fatalf("WithStackDepth() got %d frames, want %d", len(frames), tt.wantLen)
+			}
+			if tt.wantLen > 0 && frames[0].function != tt.wantFirst {
+				t.Errorf("WithStackDepth() first frame = %s, want %s", frames[0].function, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestSetDefaultStackDepth(t *testing.T) {
+	defer SetDefaultStackDepth(DefaultStackDepth())
+
+	SetDefaultStackDepth(StackNone)
+	if e := New(); e.stack != nil {
+		t.Errorf("SetDefaultStackDepth() captured a stack with StackNone")
+	}
+
+	SetDefaultStackDepth(StackCaller)
+	if got := len(New().stack.Frames()); got != 1 {
+		t.Errorf("SetDefaultStackDepth() got %d frames, want 1", got)
+	}
+
+	if got := len(New(WithStackDepth(StackFull)).stack.Frames()); got < 1 {
+		t.Errorf("SetDefaultStackDepth() WithStackDepth did not override the default")
+	}
+
+	// negative depths other than StackFull capture the full stack
+	full := len(New(WithStackDepth(StackFull)).stack.Frames())
+	if got := len(New(WithStackDepth(-5)).stack.Frames()); got != full {
+		t.Errorf("WithStackDepth(-5) got %d frames, want %d", got, full)
+	}
+	SetDefaultStackDepth(-2)
+	if got := len(New().stack.Frames()); got != full {
+		t.Errorf("SetDefaultStackDepth(-2) got %d frames, want %d", got, full)
+	}
+	func() {
+		e := New()
+		defer e.WrapPanic(WithStackDepth(-3))
+		panic("panicking")
+	}()
+}
+
+// stackOuter and stackInner are helpers for TestWithStackDepth_ExcludeFrames
+func stackOuter(depth StackDepth) *Error {
+	return stackInner(depth)
+}
+
+func stackInner(depth StackDepth) *Error {
+	return New(WithStackDepth(depth))
+}
+
+func TestWithStackDepth_ExcludeFrames(t *testing.T) {
+	defer ResetFrameFilters()
+	ExcludeFrames("github.com/bjatkin/bear.stackInner")
+
+	// the depth limit should be applied after the excluded frames are removed
+	frames := stackOuter(2).stack.Frames()
+	var got []string
+	for _, frame := range frames {
+		got = append(got, frame.function)
+	}
+	want := []string{"github.com/bjatkin/bear.stackOuter", "github.com/bjatkin/bear.TestWithStackDepth_ExcludeFrames"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithStackDepth(2) frames = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkStackDepth(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		depth StackDepth
+	}{
+		{"none", StackNone},
+		{"caller", StackCaller},
+		{"depth 5", 5},
+		{"full", StackFull},
+	}
+	for _, bm := range benchmarks {
+		b.Run("new "+bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = New(WithStackDepth(bm.depth))
+			}
+		})
+		b.Run("symbolized "+bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = getStackTrace(1, bm.depth).Frames()
+			}
+		})
+	}
+}
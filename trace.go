@@ -0,0 +1,57 @@
+package bear
+
+import "context"
+
+// traceKey is the key for the trace stored in a context
+type traceKey struct{}
+
+// trace is the trace and span an error was created in
+type trace struct {
+	traceID string
+	spanID  string
+}
+
+// WithTrace sets the trace and span ids of the error so it can be joined with distributed traces,
+// e.g. the ids from a W3C traceparent header
+func WithTrace(traceID, spanID string) ErrOption {
+	return func(e *Error) {
+		e.traceID = traceID
+		e.spanID = spanID
+	}
+}
+
+// ContextWithTrace returns a copy of the context with the trace and span ids,
+// the ids are added to every error created with NewCtx or WrapCtx
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace{traceID: traceID, spanID: spanID})
+}
+
+// TraceFromContext returns the trace and span ids stored in the context
+func TraceFromContext(ctx context.Context) (traceID, spanID string, ok bool) {
+	if ctx == nil {
+		return "", "", false
+	}
+
+	t, ok := ctx.Value(traceKey{}).(trace)
+	return t.traceID, t.spanID, ok
+}
+
+// contextTrace returns an option that sets the trace stored in the context
+func contextTrace(ctx context.Context) []ErrOption {
+	traceID, spanID, ok := TraceFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return []ErrOption{WithTrace(traceID, spanID)}
+}
+
+// GetTraceID returns the trace id of the error
+func (e *Error) GetTraceID() (string, bool) {
+	return e.traceID, e.traceID != ""
+}
+
+// GetSpanID returns the span id of the error
+func (e *Error) GetSpanID() (string, bool) {
+	return e.spanID, e.spanID != ""
+}
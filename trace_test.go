@@ -0,0 +1,72 @@
+package bear
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWithTrace(t *testing.T) {
+	ctx := ContextWithTrace(context.Background(), "trace-1", "span-1")
+
+	tests := []struct {
+		name      string
+		err       *Error
+		wantTrace string
+		wantSpan  string
+	}{
+		{"no trace", New(), "", ""},
+		{"option", New(WithTrace("trace-2", "span-2")), "trace-2", "span-2"},
+		{"context", NewCtx(ctx), "trace-1", "span-1"},
+		{"wrapped context", WrapCtx(ctx, New()), "trace-1", "span-1"},
+		{"option overrides context", NewCtx(ctx, WithTrace("trace-2", "span-2")), "trace-2", "span-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, ok := tt.err.GetTraceID()
+			if traceID != tt.wantTrace || ok != (tt.wantTrace != "") {
+				t.Errorf("GetTraceID() = %s, %v, want %s", traceID, ok, tt.wantTrace)
+			}
+			spanID, ok := tt.err.GetSpanID()
+			if spanID != tt.wantSpan || ok != (tt.wantSpan != "") {
+				t.Errorf("GetSpanID() = %s, %v, want %s", spanID, ok, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestTraceFromContext(t *testing.T) {
+	if _, _, ok := TraceFromContext(context.Background()); ok {
+		t.Errorf("TraceFromContext() found a trace in an empty context")
+	}
+
+	traceID, spanID, ok := TraceFromContext(ContextWithTrace(context.Background(), "trace", "span"))
+	if traceID != "trace" || spanID != "span" || !ok {
+		t.Errorf("TraceFromContext() = %s, %s, %v, want trace, span, true", traceID, spanID, ok)
+	}
+}
+
+func TestWithTrace_Output(t *testing.T) {
+	e := New(WithTrace("trace", "span"), WithID("id"), FmtNoStack(true))
+
+	want := `{"id":"id","traceId":"trace","spanId":"span"}`
+	if got := e.Error(); got != want {
+		t.Errorf("Error() = %s, want %s", got, want)
+	}
+
+	parsed, err := ParseJSON([]byte(e.Error()))
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	if traceID, _ := parsed.GetTraceID(); traceID != "trace" {
+		t.Errorf("ParseJSON() trace id = %s, want trace", traceID)
+	}
+	if spanID, _ := parsed.GetSpanID(); spanID != "span" {
+		t.Errorf("ParseJSON() span id = %s, want span", spanID)
+	}
+
+	if got := fmt.Sprintf("%+v", e); !strings.Contains(got, "\ntrace: trace span: span") {
+		t.Errorf("Format() = %s, want the trace", got)
+	}
+}